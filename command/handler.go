@@ -0,0 +1,323 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyper-notify-bot/config"
+	"hyper-notify-bot/formatter"
+	"hyper-notify-bot/i18n"
+	"hyper-notify-bot/scheduler"
+	"hyper-notify-bot/service"
+	"hyper-notify-bot/telegram"
+)
+
+// commandNames 机器人命令菜单中的命令，说明文案见 i18n 的 command.<命令>
+var commandNames = []string{"price", "table", "chart", "coins", "subscribe", "unsubscribe", "mysubs", "status", "help"}
+
+// Commands 返回 locale 语言的命令菜单
+func Commands(locale string) []telegram.BotCommand {
+	commands := make([]telegram.BotCommand, 0, len(commandNames))
+	for _, name := range commandNames {
+		commands = append(commands, telegram.BotCommand{Command: name, Description: i18n.T(locale, "command."+name)})
+	}
+	return commands
+}
+
+// Handler 处理用户发送给机器人的命令
+type Handler struct {
+	Bot         *telegram.TelegramBot
+	DataService *service.DataService
+	Scheduler   *scheduler.CronScheduler
+
+	startedAt time.Time
+}
+
+// NewHandler 创建命令处理器
+func NewHandler(bot *telegram.TelegramBot,
+	dataService *service.DataService,
+	cronScheduler *scheduler.CronScheduler) *Handler {
+	return &Handler{
+		Bot:         bot,
+		DataService: dataService,
+		Scheduler:   cronScheduler,
+		startedAt:   time.Now(),
+	}
+}
+
+// HandleUpdate 处理一条 Telegram 更新，响应命令消息和内联键盘按钮回调
+func (h *Handler) HandleUpdate(update telegram.Update) {
+	if update.CallbackQuery != nil {
+		h.handleCallback(update.CallbackQuery)
+		return
+	}
+
+	msg := update.Message
+	if msg == nil || msg.Text == "" {
+		return
+	}
+
+	cfg := h.Scheduler.CurrentConfig()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.JobTimeout)
+	defer cancel()
+
+	// 获取失败时带 @bot 后缀的命令会被忽略，不带后缀的命令不受影响
+	username, err := h.Bot.Username(ctx)
+	if err != nil {
+		log.Printf("%v", err)
+	}
+	name, args, ok := telegram.ParseCommand(msg.Text, username)
+	if !ok {
+		return
+	}
+
+	target := telegram.Target{ChatID: strconv.FormatInt(msg.Chat.ID, 10), ThreadID: msg.MessageThreadID}
+	lang := locale(cfg, target.ChatID, msg.From)
+	log.Printf("收到命令 /%s %v (chat %s)", name, args, target)
+
+	if name == "chart" {
+		if err := h.chart(ctx, cfg, lang, target, args); err != nil {
+			log.Printf("处理命令 /%s 失败: %v", name, err)
+			reply := i18n.T(lang, "error.prefix", html.EscapeString(err.Error()))
+			if _, err := h.Bot.SendWithRetry(ctx, target, reply, "HTML", cfg); err != nil {
+				log.Printf("回复命令 /%s 失败: %v", name, err)
+			}
+		}
+		return
+	}
+
+	reply, keyboard, err := h.dispatch(ctx, cfg, lang, msg, name, args)
+	if err != nil {
+		log.Printf("处理命令 /%s 失败: %v", name, err)
+		reply = i18n.T(lang, "error.prefix", html.EscapeString(err.Error()))
+	}
+	if reply == "" {
+		return
+	}
+
+	if _, err := h.Bot.SendWithKeyboard(ctx, target, reply, "HTML", keyboard, cfg); err != nil {
+		log.Printf("回复命令 /%s 失败: %v", name, err)
+	}
+}
+
+// locale 返回回复使用的语言：会话在推送目标中时使用其语言，否则使用发送者的 Telegram 语言，都不支持时使用默认语言
+func locale(cfg *config.Config, chatID string, from *telegram.User) string {
+	if lang := cfg.LocaleFor(chatID); lang != "" {
+		return lang
+	}
+	if from != nil {
+		if lang := i18n.Match(from.LanguageCode); lang != "" {
+			return lang
+		}
+	}
+	return cfg.Locale
+}
+
+// dispatch 执行命令并返回 HTML 格式的回复及可选的内联键盘
+func (h *Handler) dispatch(ctx context.Context, cfg *config.Config, lang string, msg *telegram.Message, name string, args []string) (string, *telegram.InlineKeyboardMarkup, error) {
+	var reply string
+	var err error
+	switch name {
+	case "start", "help":
+		reply = h.help(lang)
+	case "price":
+		reply, err = h.price(cfg, lang, args)
+	case "table":
+		return h.table(ctx, cfg, lang, args)
+	case "coins":
+		reply = h.coins(cfg, lang)
+	case "status":
+		reply = h.status(cfg, lang)
+	case "subscribe":
+		reply, err = h.subscribe(ctx, cfg, lang, msg, args)
+	case "unsubscribe":
+		reply, err = h.unsubscribe(ctx, cfg, lang, msg, args)
+	case "mysubs":
+		reply, err = h.mySubs(ctx, lang, msg)
+	}
+	return reply, nil, err
+}
+
+func (h *Handler) help(lang string) string {
+	var b strings.Builder
+	b.WriteString("<b>" + html.EscapeString(i18n.T(lang, "help.title")) + "</b>\n\n")
+	for _, cmd := range Commands(lang) {
+		fmt.Fprintf(&b, "/%s - %s\n", cmd.Command, html.EscapeString(cmd.Description))
+	}
+	return b.String()
+}
+
+func (h *Handler) price(cfg *config.Config, lang string, args []string) (string, error) {
+	coins := cfg.Coins
+	if len(args) > 0 {
+		coin, err := findCoin(cfg, lang, args[0])
+		if err != nil {
+			return "", err
+		}
+		coins = []string{coin}
+	}
+
+	var b strings.Builder
+	b.WriteString("<b>" + html.EscapeString(i18n.T(lang, "price.title")) + "</b>\n")
+	for _, coin := range coins {
+		b.WriteString("\n" + h.priceLine(lang, coin))
+	}
+	return b.String(), nil
+}
+
+func (h *Handler) table(ctx context.Context, cfg *config.Config, lang string, args []string) (string, *telegram.InlineKeyboardMarkup, error) {
+	if len(args) == 0 {
+		return "", nil, needCoin(cfg, lang, "table")
+	}
+	coin, err := findCoin(cfg, lang, args[0])
+	if err != nil {
+		return "", nil, err
+	}
+	return h.tableView(ctx, cfg, lang, coin, 0)
+}
+
+// chart 以图片回复仓位分布图
+func (h *Handler) chart(ctx context.Context, cfg *config.Config, lang string, target telegram.Target, args []string) error {
+	if len(args) == 0 {
+		return needCoin(cfg, lang, "chart")
+	}
+	coin, err := findCoin(cfg, lang, args[0])
+	if err != nil {
+		return err
+	}
+
+	report, err := h.DataService.Report(ctx, coin, h.DataService.OraclePrice(ctx, coin), 0)
+	if err != nil {
+		return err
+	}
+	chart, err := report.Chart()
+	if err != nil {
+		return err
+	}
+	_, err = h.Bot.SendPhotoWithRetry(ctx, target, chart, strings.ToLower(coin)+".png", report.Caption(formatter.HTML, lang), "HTML", cfg)
+	return err
+}
+
+func (h *Handler) coins(cfg *config.Config, lang string) string {
+	return "<b>" + html.EscapeString(i18n.T(lang, "coins.title")) + "</b>\n\n" + html.EscapeString(strings.Join(cfg.Coins, ", "))
+}
+
+func (h *Handler) status(cfg *config.Config, lang string) string {
+	var b strings.Builder
+	b.WriteString("<b>" + html.EscapeString(i18n.T(lang, "status.title")) + "</b>\n\n")
+	b.WriteString(html.EscapeString(i18n.T(lang, "status.uptime", time.Since(h.startedAt).Round(time.Second))) + "\n")
+	for _, coin := range cfg.Coins {
+		b.WriteString("\n" + h.priceLine(lang, coin))
+		if next := h.Scheduler.NextRun(coin); !next.IsZero() {
+			b.WriteString("\n    " + html.EscapeString(i18n.T(lang, "status.next_run", next.Format("2006-01-02 15:04:05 MST"))))
+		}
+	}
+	return b.String()
+}
+
+func (h *Handler) subscribe(ctx context.Context, cfg *config.Config, lang string, msg *telegram.Message, args []string) (string, error) {
+	if msg.From == nil {
+		return "", errors.New(i18n.T(lang, "error.no_sender"))
+	}
+	if len(args) == 0 {
+		return "", needCoin(cfg, lang, "subscribe")
+	}
+	coin, err := findCoin(cfg, lang, args[0])
+	if err != nil {
+		return "", err
+	}
+
+	// 私信使用订阅时的语言
+	added, err := h.DataService.Subscribe(ctx, msg.From.ID, msg.From.Username, coin, lang)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if added {
+		b.WriteString(i18n.T(lang, "subscribe.added", boldCoin(coin)))
+	} else {
+		b.WriteString(i18n.T(lang, "subscribe.exists", boldCoin(coin)))
+	}
+	// 机器人只能私信主动发起过对话的用户，未 /start 的用户私信会失败但不会被删除订阅
+	b.WriteString("\n\n" + html.EscapeString(i18n.T(lang, "subscribe.start")))
+	return b.String(), nil
+}
+
+func (h *Handler) unsubscribe(ctx context.Context, cfg *config.Config, lang string, msg *telegram.Message, args []string) (string, error) {
+	if msg.From == nil {
+		return "", errors.New(i18n.T(lang, "error.no_sender"))
+	}
+	if len(args) == 0 {
+		return "", needCoin(cfg, lang, "unsubscribe")
+	}
+
+	// 已从配置中移除的币种也允许取消订阅
+	coin := args[0]
+	if found, err := findCoin(cfg, lang, coin); err == nil {
+		coin = found
+	}
+
+	removed, err := h.DataService.Unsubscribe(ctx, msg.From.ID, coin)
+	if err != nil {
+		return "", err
+	}
+	if !removed {
+		return i18n.T(lang, "unsubscribe.missing", boldCoin(coin)), nil
+	}
+	return i18n.T(lang, "unsubscribe.removed", boldCoin(coin)), nil
+}
+
+func (h *Handler) mySubs(ctx context.Context, lang string, msg *telegram.Message) (string, error) {
+	if msg.From == nil {
+		return "", errors.New(i18n.T(lang, "error.no_sender"))
+	}
+
+	coins, err := h.DataService.Subscriptions(ctx, msg.From.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(coins) == 0 {
+		return html.EscapeString(i18n.T(lang, "mysubs.empty")), nil
+	}
+	return "<b>" + html.EscapeString(i18n.T(lang, "mysubs.title")) + "</b>\n\n" + html.EscapeString(strings.Join(coins, ", ")), nil
+}
+
+// priceLine 返回单个币种 WebSocket 推送的价格及更新时间，价格过期时加以标注
+func (h *Handler) priceLine(lang, coin string) string {
+	price := h.DataService.FeedPrice(coin)
+	if price.Timestamp.IsZero() {
+		return boldCoin(coin) + ": N/A"
+	}
+	line := i18n.T(lang, "price.updated", price.OraclePx, price.Age().Round(time.Second))
+	if price.Stale {
+		line += " " + i18n.T(lang, "price.stale")
+	}
+	return boldCoin(coin) + ": " + html.EscapeString(line)
+}
+
+// boldCoin 返回加粗的币种名称
+func boldCoin(coin string) string {
+	return "<b>" + html.EscapeString(coin) + "</b>"
+}
+
+// needCoin 返回缺少币种参数的提示
+func needCoin(cfg *config.Config, lang, command string) error {
+	return errors.New(i18n.T(lang, "error.need_coin", command, cfg.Coins[0]))
+}
+
+// findCoin 在配置的币种中查找（不区分大小写）
+func findCoin(cfg *config.Config, lang, name string) (string, error) {
+	for _, coin := range cfg.Coins {
+		if strings.EqualFold(coin, name) {
+			return coin, nil
+		}
+	}
+	return "", errors.New(i18n.T(lang, "error.unknown_coin", name, strings.Join(cfg.Coins, ", ")))
+}
@@ -0,0 +1,136 @@
+package command
+
+import (
+	"context"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	"hyper-notify-bot/config"
+	"hyper-notify-bot/i18n"
+	"hyper-notify-bot/telegram"
+)
+
+// tableCallbackPrefix 仓位分布表按钮的 callback_data 前缀，格式为 table:<币种>:<缩放级别>
+const tableCallbackPrefix = "table:"
+
+// maxCallbackData Telegram 限制 callback_data 最长 64 字节
+const maxCallbackData = 64
+
+// coinsPerRow 键盘每行的币种按钮数
+const coinsPerRow = 4
+
+// tableCallbackData 生成切换到指定币种和缩放级别的 callback_data
+func tableCallbackData(coin string, zoom int) string {
+	return tableCallbackPrefix + coin + ":" + strconv.Itoa(zoom)
+}
+
+// parseTableCallback 解析仓位分布表按钮的 callback_data
+func parseTableCallback(data string) (string, int, bool) {
+	rest, ok := strings.CutPrefix(data, tableCallbackPrefix)
+	if !ok {
+		return "", 0, false
+	}
+	// 币种名称可能包含冒号，缩放级别取最后一段
+	i := strings.LastIndex(rest, ":")
+	if i <= 0 {
+		return "", 0, false
+	}
+	zoom, err := strconv.Atoi(rest[i+1:])
+	if err != nil || zoom < -config.MaxZoom || zoom > config.MaxZoom {
+		return "", 0, false
+	}
+	return rest[:i], zoom, true
+}
+
+// tableKeyboard 生成仓位分布表下方的键盘：切换币种、放大缩小价格窗口、刷新
+func tableKeyboard(cfg *config.Config, lang, coin string, zoom int) *telegram.InlineKeyboardMarkup {
+	var rows [][]telegram.InlineKeyboardButton
+
+	var row []telegram.InlineKeyboardButton
+	for _, c := range cfg.Coins {
+		data := tableCallbackData(c, 0)
+		if len(data) > maxCallbackData {
+			continue
+		}
+		text := c
+		if c == coin {
+			text = "• " + c + " •"
+		}
+		row = append(row, telegram.InlineKeyboardButton{Text: text, CallbackData: data})
+		if len(row) == coinsPerRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	var controls []telegram.InlineKeyboardButton
+	if zoom < config.MaxZoom {
+		controls = append(controls, telegram.InlineKeyboardButton{Text: i18n.T(lang, "keyboard.zoom_in"), CallbackData: tableCallbackData(coin, zoom+1)})
+	}
+	if zoom > -config.MaxZoom {
+		controls = append(controls, telegram.InlineKeyboardButton{Text: i18n.T(lang, "keyboard.zoom_out"), CallbackData: tableCallbackData(coin, zoom-1)})
+	}
+	controls = append(controls, telegram.InlineKeyboardButton{Text: i18n.T(lang, "keyboard.refresh"), CallbackData: tableCallbackData(coin, zoom)})
+	rows = append(rows, controls)
+
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// tableView 生成指定币种和缩放级别的仓位分布表及键盘
+func (h *Handler) tableView(ctx context.Context, cfg *config.Config, lang, coin string, zoom int) (string, *telegram.InlineKeyboardMarkup, error) {
+	price := h.DataService.OraclePrice(ctx, coin)
+	report, err := h.DataService.ZoomedTableReport(ctx, coin, price, lang, zoom)
+	if err != nil {
+		return "", nil, err
+	}
+	if zoom != 0 {
+		settings := h.DataService.ZoomedSettings(coin, price.OraclePx, zoom)
+		report += "\n<i>" + html.EscapeString(i18n.T(lang, "report.zoom", zoom, settings.PriceRangeRatio*100, settings.BinSize)) + "</i>"
+	}
+	return report, tableKeyboard(cfg, lang, coin, zoom), nil
+}
+
+// handleCallback 处理仓位分布表键盘的按钮回调，在原消息上重新生成表格
+func (h *Handler) handleCallback(query *telegram.CallbackQuery) {
+	cfg := h.Scheduler.CurrentConfig()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.JobTimeout)
+	defer cancel()
+
+	name, zoom, ok := parseTableCallback(query.Data)
+	if !ok || query.Message == nil {
+		h.answerCallback(ctx, query, i18n.T(locale(cfg, "", &query.From), "keyboard.expired"), false)
+		return
+	}
+	log.Printf("收到按钮回调 %s (chat %d, 用户 %d)", query.Data, query.Message.Chat.ID, query.From.ID)
+
+	target := telegram.Target{ChatID: strconv.FormatInt(query.Message.Chat.ID, 10)}
+	lang := locale(cfg, target.ChatID, &query.From)
+	coin, err := findCoin(cfg, lang, name)
+	if err != nil {
+		h.answerCallback(ctx, query, err.Error(), true)
+		return
+	}
+
+	text, keyboard, err := h.tableView(ctx, cfg, lang, coin, zoom)
+	if err == nil {
+		err = h.Bot.EditMessageText(ctx, target, query.Message.MessageID, text, "HTML", keyboard)
+	}
+	if err != nil {
+		log.Printf("处理按钮回调 %s 失败: %v", query.Data, err)
+		h.answerCallback(ctx, query, i18n.T(lang, "error.prefix", err.Error()), true)
+		return
+	}
+	h.answerCallback(ctx, query, "", false)
+}
+
+// answerCallback 响应按钮回调，结束客户端的加载状态
+func (h *Handler) answerCallback(ctx context.Context, query *telegram.CallbackQuery, text string, alert bool) {
+	if err := h.Bot.AnswerCallbackQuery(ctx, query.ID, text, alert); err != nil {
+		log.Printf("响应按钮回调失败: %v", err)
+	}
+}
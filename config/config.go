@@ -1,21 +1,48 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"hyper-notify-bot/i18n"
 )
 
 type Config struct {
-	TelegramToken  string
-	TelegramChatID string
-	TelegramProxy  string
-	Interval       time.Duration
-	RetryCount     int
-	RetryDelay     time.Duration
+	TelegramToken    string
+	TelegramChatID   string
+	TelegramThreadID int64 // 默认推送目标的论坛话题 ID
+	TelegramProxy    string
+	TelegramAPIURL   string // Bot API 地址，为空时使用 https://api.telegram.org
+	AlertChatID      string // 接收运维告警（如 Oracle 价格过期）的 Telegram chat_id，为空时只记录日志
+	// 接收用户命令的方式：polling（getUpdates 长轮询）、webhook 或 off
+	TelegramUpdateMode string
+	Webhook            WebhookConfig
+	// 定时推送的方式：post 每次发送新消息，edit 每个币种固定一条置顶消息并原地更新
+	MessageMode string
+	Interval    time.Duration
+	Schedule    string // 持续时间（如 90m）或 cron 表达式，为空时使用 Interval
+	Timezone    string // cron 表达式使用的时区，如 Asia/Shanghai
+	RetryCount  int
+	RetryDelay  time.Duration
+
+	MaxConcurrentJobs int           // 同时执行的推送任务数上限
+	JobTimeout        time.Duration // 单次推送任务的超时时间
+
+	// Hyperliquid WebSocket 心跳：每隔 WSPingInterval 发送 ping，超过 WSIdleTimeout 未收到任何消息时重连
+	WSPingInterval time.Duration
+	WSIdleTimeout  time.Duration
+
+	// Oracle 价格超过 PriceStaleAfter 未更新视为过期，可按币种覆盖；
+	// PriceRESTFallback 为 true 时，价格缺失或过期改用 REST 接口查询的快照
+	PriceStaleAfter   time.Duration
+	PriceRESTFallback bool
 
 	// MongoDB配置
 	MongoURI        string
@@ -23,48 +50,539 @@ type Config struct {
 	MongoCollection string
 
 	HyperliquidCoin string
-	PriceRangeRatio float64 `json:"price_range_ratio"`
+	Coins           []string // 订阅并定时推送的币种列表
+	PriceRangeRatio float64  `json:"price_range_ratio"`
+	WindowRows      int      // 表格默认展示行数
+	Chart           string   // 是否发送 PNG 图表：off 仅表格，with 表格和图表，only 仅图表
+	TemplatesDir    string   // 消息模板目录，其中的 *.tmpl 覆盖同名的内置模板，为空时仅使用内置模板
+	Locale          string   // 消息语言 zh 或 en，可在推送目标中单独设置
+
+	// 按币种覆盖的统计与展示参数
+	CoinSettings map[string]CoinSettings
+
+	// 推送目标，未配置时使用 TelegramChatID 推送所有币种
+	Chats []ChatConfig
+
+	// 邮件日报
+	Email EmailConfig
+
+	// 加载的配置文件路径，为空表示仅使用环境变量
+	File string
+}
+
+// 接收用户命令的方式
+const (
+	UpdateModePolling = "polling"
+	UpdateModeWebhook = "webhook"
+	UpdateModeOff     = "off"
+)
+
+// 定时推送的方式
+const (
+	MessageModePost = "post"
+	MessageModeEdit = "edit"
+)
+
+// 图表发送方式
+const (
+	ChartOff  = "off"
+	ChartWith = "with"
+	ChartOnly = "only"
+)
+
+// WebhookConfig webhook 模式配置
+type WebhookConfig struct {
+	URL      string `yaml:"url"`       // Telegram 推送更新的公网地址，路径部分同时作为本地监听路径
+	Listen   string `yaml:"listen"`    // 本地监听地址，如 :8443
+	Secret   string `yaml:"secret"`    // setWebhook 的 secret_token，用于校验请求来源
+	CertFile string `yaml:"cert_file"` // 可选，配置后直接提供 HTTPS 服务
+	KeyFile  string `yaml:"key_file"`
+}
+
+// AutoPrecision 表示价格小数位数根据分箱宽度自动推导
+const AutoPrecision = -1
+
+// CoinSettings 单个币种的统计与展示参数
+type CoinSettings struct {
+	BinSize         float64       // 价格分箱宽度，0 表示根据 Oracle 价格自动推导
+	PriceRangeRatio float64       // 统计的价格窗口，相对 Oracle 价格上下浮动的比例
+	WindowRows      int           // 表格最多展示的行数（以最接近 Oracle 价格的行为中心）
+	Precision       int           // 价格显示的小数位数，AutoPrecision 表示自动
+	Schedule        string        // 覆盖全局推送计划，格式同 Config.Schedule
+	StaleAfter      time.Duration // Oracle 价格超过该时长未更新视为过期，0 表示使用 Config.PriceStaleAfter
+}
+
+// ChatConfig 单个推送目标（路由表中的一项）
+type ChatConfig struct {
+	Type     string   `yaml:"type"`      // 推送渠道 telegram（默认）、discord、slack 或 webhook
+	URL      string   `yaml:"url"`       // discord、slack、webhook 渠道的 Webhook 地址
+	ID       string   `yaml:"id"`        // Telegram chat_id
+	ThreadID int64    `yaml:"thread_id"` // 论坛话题的 message_thread_id，0 表示不指定
+	Coins    []string `yaml:"coins"`     // 为空表示推送所有币种
+	Format   string   `yaml:"format"`    // 消息格式，为空时使用 html
+	Schedule string   `yaml:"schedule"`  // 覆盖币种推送计划，格式同 Config.Schedule
+	Mode     string   `yaml:"mode"`      // 推送方式 post 或 edit，为空时使用 Config.MessageMode
+	Chart    string   `yaml:"chart"`     // 图表发送方式 off、with 或 only，为空时使用 Config.Chart；edit 模式不发送图表
+	Locale   string   `yaml:"locale"`    // 消息语言，为空时使用 Config.Locale
+}
+
+// 推送渠道
+const (
+	RouteTelegram = "telegram"
+	RouteDiscord  = "discord"
+	RouteSlack    = "slack"
+	RouteWebhook  = "webhook"
+)
+
+// 消息格式
+const (
+	FormatHTML       = "html"
+	FormatMarkdownV2 = "markdownv2" // 仅 Telegram 支持
+	FormatText       = "text"
+)
+
+// defaultCoins 未配置 COINS 时使用的默认币种
+var defaultCoins = []string{"HYPE", "BTC", "ETH", "SOL"}
+
+// defaultBinSizes 常用币种的默认分箱宽度，其余币种根据价格自动推导
+var defaultBinSizes = map[string]float64{
+	"BTC":  100,
+	"ETH":  10,
+	"SOL":  1,
+	"HYPE": 0.5,
+}
+
+const (
+	defaultConfigFile      = "config.yaml"
+	defaultWebhookListen   = ":8080"
+	defaultInterval        = 1 * time.Minute
+	defaultRetryCount      = 3
+	defaultRetryDelay      = 5 * time.Second
+	defaultMaxJobs         = 2
+	defaultJobTimeout      = 2 * time.Minute
+	defaultWSPingInterval  = 30 * time.Second
+	defaultWSIdleTimeout   = 60 * time.Second
+	defaultPriceStaleAfter = 2 * time.Minute
+	defaultPriceRangeRatio = 0.05
+	defaultWindowRows      = 20
+	// autoBinCount 自动推导分箱宽度时，价格窗口内期望的分箱数量
+	autoBinCount = 40
+)
+
+// CoinChecker 返回 coins 中 Hyperliquid 未上线的币种
+type CoinChecker func(coins []string) ([]string, error)
+
+// LoadConfig 加载配置：配置文件（CONFIG_FILE，默认 config.yaml，可选）→ .env（可选）→ 环境变量覆盖
+func LoadConfig(check CoinChecker) (*Config, error) {
+	return Load(FilePath(), check)
+}
+
+// FilePath 返回配置文件路径，未设置 CONFIG_FILE 且默认文件不存在时返回空
+func FilePath() string {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path
+	}
+	if _, err := os.Stat(defaultConfigFile); err == nil {
+		return defaultConfigFile
+	}
+	return ""
 }
 
-func LoadConfig() (*Config, error) {
-	// 加载环境变量
-	if err := godotenv.Load(); err != nil {
+// Load 从指定配置文件和环境变量加载配置并校验，校验失败时一次性返回所有错误；
+// check 不为 nil 时同时确认 Hyperliquid 已上线配置的币种
+func Load(path string, check CoinChecker) (*Config, error) {
+	// 加载环境变量（.env 不存在时直接使用进程环境变量）
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
 		return nil, fmt.Errorf("加载.env文件失败: %v", err)
 	}
 
-	intervalStr := os.Getenv("INTERVAL")
-	var interval time.Duration
-	var err error
-
-	if intervalStr != "" {
-		// 尝试解析复杂持续时间格式（如 "1h30m"）
-		interval, err = time.ParseDuration(intervalStr)
-		if err != nil {
-			// 如果解析失败，尝试作为纯数字分钟数解析
-			intervalVal, err2 := strconv.Atoi(intervalStr)
-			if err2 != nil {
-				// 如果两种方式都失败，使用默认值
-				interval = 1 * time.Minute
-			} else {
-				interval = time.Duration(intervalVal) * time.Minute
+	cfg := &Config{
+		TelegramUpdateMode: UpdateModePolling,
+		Webhook:            WebhookConfig{Listen: defaultWebhookListen},
+		MessageMode:        MessageModePost,
+		Chart:              ChartOff,
+		Locale:             i18n.Default,
+		Email: EmailConfig{
+			Port:     defaultSMTPPort,
+			TLS:      EmailTLSStartTLS,
+			Schedule: defaultDigestSchedule,
+		},
+		Interval:          defaultInterval,
+		RetryCount:        defaultRetryCount, // 最大重试次数
+		RetryDelay:        defaultRetryDelay, // 重试延迟
+		MaxConcurrentJobs: defaultMaxJobs,
+		JobTimeout:        defaultJobTimeout,
+		WSPingInterval:    defaultWSPingInterval,
+		WSIdleTimeout:     defaultWSIdleTimeout,
+		PriceStaleAfter:   defaultPriceStaleAfter,
+		PriceRangeRatio:   defaultPriceRangeRatio,
+		WindowRows:        defaultWindowRows,
+		CoinSettings:      make(map[string]CoinSettings),
+		File:              path,
+	}
+
+	var errs []error
+	if path != "" {
+		errs = append(errs, loadFile(path, cfg)...)
+	}
+
+	errs = append(errs, applyEnv(cfg)...)
+	cfg.applyDefaults()
+	errs = append(errs, cfg.Validate()...)
+	if check != nil {
+		errs = append(errs, cfg.checkCoins(check)...)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("配置校验失败:\n%w", errors.Join(errs...))
+	}
+	return cfg, nil
+}
+
+// applyEnv 使用环境变量覆盖配置项
+func applyEnv(cfg *Config) []error {
+	env := &envLoader{}
+
+	env.str("TELEGRAM_BOT_TOKEN", &cfg.TelegramToken)
+	env.str("TELEGRAM_CHAT_ID", &cfg.TelegramChatID)
+	env.int64("TELEGRAM_THREAD_ID", &cfg.TelegramThreadID)
+	env.str("TELEGRAM_PROXY", &cfg.TelegramProxy)
+	env.str("TELEGRAM_API_URL", &cfg.TelegramAPIURL)
+	env.str("TELEGRAM_ALERT_CHAT_ID", &cfg.AlertChatID)
+	env.str("TELEGRAM_UPDATE_MODE", &cfg.TelegramUpdateMode)
+	env.str("TELEGRAM_MESSAGE_MODE", &cfg.MessageMode)
+	env.str("CHART", &cfg.Chart)
+	env.str("TEMPLATES_DIR", &cfg.TemplatesDir)
+	env.str("LOCALE", &cfg.Locale)
+	env.str("TELEGRAM_WEBHOOK_URL", &cfg.Webhook.URL)
+	env.str("TELEGRAM_WEBHOOK_LISTEN", &cfg.Webhook.Listen)
+	env.str("TELEGRAM_WEBHOOK_SECRET", &cfg.Webhook.Secret)
+	env.str("TELEGRAM_WEBHOOK_CERT", &cfg.Webhook.CertFile)
+	env.str("TELEGRAM_WEBHOOK_KEY", &cfg.Webhook.KeyFile)
+	env.str("MONGO_URI", &cfg.MongoURI)
+	env.str("MONGO_DB", &cfg.MongoDB)
+	env.str("MONGO_COLLECTION", &cfg.MongoCollection)
+	env.str("HYPERLIQUID_COIN", &cfg.HyperliquidCoin)
+	env.interval("INTERVAL", &cfg.Interval)
+	env.str("SCHEDULE", &cfg.Schedule)
+	env.str("TIMEZONE", &cfg.Timezone)
+	env.int("MAX_CONCURRENT_JOBS", &cfg.MaxConcurrentJobs)
+	env.interval("JOB_TIMEOUT", &cfg.JobTimeout)
+	env.interval("WS_PING_INTERVAL", &cfg.WSPingInterval)
+	env.interval("WS_IDLE_TIMEOUT", &cfg.WSIdleTimeout)
+	env.interval("PRICE_STALE_AFTER", &cfg.PriceStaleAfter)
+	env.bool("PRICE_REST_FALLBACK", &cfg.PriceRESTFallback)
+	env.str("SMTP_HOST", &cfg.Email.Host)
+	env.int("SMTP_PORT", &cfg.Email.Port)
+	env.str("SMTP_USERNAME", &cfg.Email.Username)
+	env.str("SMTP_PASSWORD", &cfg.Email.Password)
+	env.str("SMTP_FROM", &cfg.Email.From)
+	env.str("SMTP_TLS", &cfg.Email.TLS)
+	env.str("DIGEST_SCHEDULE", &cfg.Email.Schedule)
+	env.str("DIGEST_SUBJECT", &cfg.Email.Subject)
+	if to := ParseList(os.Getenv("SMTP_TO")); len(to) > 0 {
+		cfg.Email.To = to
+	}
+	env.float("PRICE_RANGE_RATIO", &cfg.PriceRangeRatio)
+	env.int("WINDOW_ROWS", &cfg.WindowRows)
+
+	if coins := ParseCoins(os.Getenv("COINS")); len(coins) > 0 {
+		cfg.Coins = coins
+		// 环境变量缩小了配置文件的币种列表时，不再推送的币种的单独配置随之忽略
+		for coin := range cfg.CoinSettings {
+			if !cfg.HasCoin(coin) {
+				delete(cfg.CoinSettings, coin)
+			}
+		}
+	}
+	if len(cfg.Coins) == 0 {
+		cfg.Coins = ParseCoins(cfg.HyperliquidCoin)
+	}
+	if len(cfg.Coins) == 0 {
+		cfg.Coins = append([]string(nil), defaultCoins...)
+	}
+
+	// 按币种覆盖，如 BTC_BIN_SIZE、BTC_PRICE_RANGE_RATIO、BTC_WINDOW_ROWS、BTC_PRECISION
+	for _, coin := range cfg.Coins {
+		settings, ok := cfg.CoinSettings[coin]
+		if !ok {
+			settings = CoinSettings{BinSize: defaultBinSizes[coin], Precision: AutoPrecision}
+		}
+
+		prefix := strings.ToUpper(coin) + "_"
+		env.float(prefix+"BIN_SIZE", &settings.BinSize)
+		env.float(prefix+"PRICE_RANGE_RATIO", &settings.PriceRangeRatio)
+		env.int(prefix+"WINDOW_ROWS", &settings.WindowRows)
+		env.int(prefix+"PRECISION", &settings.Precision)
+		env.str(prefix+"SCHEDULE", &settings.Schedule)
+		env.interval(prefix+"STALE_AFTER", &settings.StaleAfter)
+		cfg.CoinSettings[coin] = settings
+	}
+
+	return env.errs
+}
+
+// applyDefaults 补全依赖其他配置项的默认值
+func (c *Config) applyDefaults() {
+	if len(c.Chats) == 0 && c.TelegramChatID != "" {
+		c.Chats = []ChatConfig{{ID: c.TelegramChatID, ThreadID: c.TelegramThreadID}}
+	}
+	for i := range c.Chats {
+		if c.Chats[i].Type == "" {
+			c.Chats[i].Type = RouteTelegram
+		}
+		if c.Chats[i].Format == "" {
+			c.Chats[i].Format = FormatHTML
+		}
+		if c.Chats[i].Mode == "" {
+			// 只有 Telegram 支持原地更新消息
+			c.Chats[i].Mode = MessageModePost
+			if c.Chats[i].Type == RouteTelegram {
+				c.Chats[i].Mode = c.MessageMode
+			}
+		}
+		if c.Chats[i].Chart == "" {
+			c.Chats[i].Chart = c.Chart
+		}
+		if c.Chats[i].Locale == "" {
+			c.Chats[i].Locale = c.Locale
+		}
+	}
+}
+
+// envLoader 读取环境变量并收集解析错误
+type envLoader struct {
+	errs []error
+}
+
+func (l *envLoader) str(key string, dst *string) {
+	if raw, ok := os.LookupEnv(key); ok && raw != "" {
+		*dst = raw
+	}
+}
+
+func (l *envLoader) float(key string, dst *float64) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return
+	}
+	val, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s 配置无效: %v", key, err))
+		return
+	}
+	*dst = val
+}
+
+func (l *envLoader) int(key string, dst *int) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return
+	}
+	val, err := strconv.Atoi(raw)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s 配置无效: %v", key, err))
+		return
+	}
+	*dst = val
+}
+
+func (l *envLoader) int64(key string, dst *int64) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return
+	}
+	val, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s 配置无效: %v", key, err))
+		return
+	}
+	*dst = val
+}
+
+func (l *envLoader) bool(key string, dst *bool) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return
+	}
+	val, err := strconv.ParseBool(raw)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s 配置无效: %v", key, err))
+		return
+	}
+	*dst = val
+}
+
+func (l *envLoader) interval(key string, dst *time.Duration) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return
+	}
+	val, err := ParseInterval(raw)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s 配置无效: %v", key, err))
+		return
+	}
+	*dst = val
+}
+
+// ParseInterval 解析时间间隔，支持持续时间格式（如 "1h30m"）或纯数字分钟数
+func ParseInterval(s string) (time.Duration, error) {
+	if interval, err := time.ParseDuration(s); err == nil {
+		return interval, nil
+	}
+	minutes, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("无法解析时间间隔 %q", s)
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
+
+// ParseCoins 将逗号分隔的币种字符串解析为去重后的列表（保留大小写，如 kPEPE）
+func ParseCoins(raw string) []string {
+	var coins []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		coin := strings.TrimSpace(part)
+		if coin == "" || seen[coin] {
+			continue
+		}
+		seen[coin] = true
+		coins = append(coins, coin)
+	}
+	return coins
+}
+
+// HasCoin 判断币种是否在订阅列表中
+func (c *Config) HasCoin(coin string) bool {
+	for _, item := range c.Coins {
+		if item == coin {
+			return true
+		}
+	}
+	return false
+}
+
+// ChatsFor 返回订阅了指定币种的推送目标
+func (c *Config) ChatsFor(coin string) []ChatConfig {
+	var chats []ChatConfig
+	for _, chat := range c.Chats {
+		if len(chat.Coins) == 0 {
+			chats = append(chats, chat)
+			continue
+		}
+		for _, item := range chat.Coins {
+			if item == coin {
+				chats = append(chats, chat)
+				break
 			}
 		}
-	} else {
-		interval = 1 * time.Minute
-	}
-
-	return &Config{
-		TelegramToken:   os.Getenv("TELEGRAM_BOT_TOKEN"),
-		TelegramChatID:  os.Getenv("TELEGRAM_CHAT_ID"),
-		TelegramProxy:   os.Getenv("TELEGRAM_PROXY"),
-		MongoURI:        os.Getenv("MONGO_URI"),
-		MongoDB:         os.Getenv("MONGO_DB"),
-		MongoCollection: os.Getenv("MONGO_COLLECTION"),
-		HyperliquidCoin: os.Getenv("HYPERLIQUID_COIN"),
-		Interval:        interval,        // 每interval分钟执行一次
-		RetryCount:      3,               // 最大重试次数
-		RetryDelay:      5 * time.Second, // 重试延迟
-		PriceRangeRatio: 0.05,
-	}, nil
+	}
+	return chats
+}
+
+// LocaleFor 返回 Telegram 会话的消息语言，会话不在推送目标中时返回空
+func (c *Config) LocaleFor(chatID string) string {
+	for _, chat := range c.Chats {
+		if chat.Type == RouteTelegram && chat.ID == chatID {
+			return chat.Locale
+		}
+	}
+	return ""
+}
+
+// SettingsFor 返回币种的参数，未单独配置的字段使用全局默认值
+func (c *Config) SettingsFor(coin string) CoinSettings {
+	settings, ok := c.CoinSettings[coin]
+	if !ok {
+		settings = CoinSettings{
+			BinSize:   defaultBinSizes[coin],
+			Precision: AutoPrecision,
+		}
+	}
+	if settings.PriceRangeRatio <= 0 {
+		settings.PriceRangeRatio = c.PriceRangeRatio
+	}
+	if settings.PriceRangeRatio <= 0 {
+		settings.PriceRangeRatio = defaultPriceRangeRatio
+	}
+	if settings.WindowRows <= 0 {
+		settings.WindowRows = c.WindowRows
+	}
+	if settings.WindowRows <= 0 {
+		settings.WindowRows = defaultWindowRows
+	}
+	if settings.StaleAfter <= 0 {
+		settings.StaleAfter = c.PriceStaleAfter
+	}
+	if settings.StaleAfter <= 0 {
+		settings.StaleAfter = defaultPriceStaleAfter
+	}
+	return settings
+}
+
+// MaxZoom 表格缩放级别的上限（正负相同）
+const MaxZoom = 3
+
+// maxZoomedRangeRatio 缩小时价格窗口比例的上限
+const maxZoomedRangeRatio = 0.9
+
+// Zoom 按缩放级别调整价格窗口和分箱宽度，每级缩放一倍：正数放大（窗口变窄、分箱更细），负数缩小，展示行数不变
+func (s CoinSettings) Zoom(level int) CoinSettings {
+	if level == 0 {
+		return s
+	}
+
+	factor := math.Pow(2, -float64(level))
+	s.PriceRangeRatio = math.Min(s.PriceRangeRatio*factor, maxZoomedRangeRatio)
+	if s.BinSize > 0 {
+		s.BinSize *= factor
+		// 固定的小数位数不足以区分更细的分箱时自动增加
+		if s.Precision != AutoPrecision && decimalPlaces(s.BinSize) > s.Precision {
+			s.Precision = decimalPlaces(s.BinSize)
+		}
+	}
+	return s
+}
+
+// Resolve 根据当前 Oracle 价格补全自动推导的分箱宽度和小数位数
+func (s CoinSettings) Resolve(oraclePrice float64) CoinSettings {
+	if s.BinSize <= 0 {
+		s.BinSize = autoBinSize(oraclePrice, s.PriceRangeRatio)
+	}
+	if s.Precision < 0 {
+		s.Precision = decimalPlaces(s.BinSize)
+	}
+	return s
+}
 
+// autoBinSize 使价格窗口内大约有 autoBinCount 个分箱，并取整到 1/2/5×10^n
+func autoBinSize(price, ratio float64) float64 {
+	if price <= 0 || ratio <= 0 {
+		return 1
+	}
+
+	raw := price * ratio * 2 / autoBinCount
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch f := raw / magnitude; {
+	case f < 1.5:
+		return magnitude
+	case f < 3.5:
+		return 2 * magnitude
+	case f < 7.5:
+		return 5 * magnitude
+	default:
+		return 10 * magnitude
+	}
+}
+
+// decimalPlaces 返回展示分箱价格所需的小数位数
+func decimalPlaces(v float64) int {
+	for d := 0; d < 8; d++ {
+		scaled := v * math.Pow(10, float64(d))
+		if math.Abs(scaled-math.Round(scaled)) < 1e-9 {
+			return d
+		}
+	}
+	return 8
 }
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// baseFile 只包含必填项的配置文件
+const baseFile = `
+telegram:
+  token: TOKEN
+  chat_id: "-100"
+mongo:
+  uri: mongodb://localhost:27017
+  db: test
+`
+
+// writeFile 在临时目录写入配置文件
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+	return path
+}
+
+func TestEnvCoinsNarrowFileCoins(t *testing.T) {
+	path := writeFile(t, baseFile+`
+coins:
+  - name: HYPE
+    bin_size: 0.5
+  - name: BTC
+    schedule: 30m
+`)
+	t.Setenv("COINS", "ETH")
+
+	cfg, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if strings.Join(cfg.Coins, ",") != "ETH" {
+		t.Errorf("Coins = %v，期望 [ETH]", cfg.Coins)
+	}
+	for coin := range cfg.CoinSettings {
+		if coin != "ETH" {
+			t.Errorf("CoinSettings 仍包含已移除的币种 %s", coin)
+		}
+	}
+}
+
+func TestEnvCoinsKeepFileSettings(t *testing.T) {
+	path := writeFile(t, baseFile+`
+coins:
+  - name: HYPE
+    bin_size: 0.5
+  - name: BTC
+`)
+	t.Setenv("COINS", "HYPE,ETH")
+
+	cfg, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.SettingsFor("HYPE").BinSize; got != 0.5 {
+		t.Errorf("HYPE 分箱宽度 = %v，期望保留配置文件中的 0.5", got)
+	}
+	if _, ok := cfg.CoinSettings["BTC"]; ok {
+		t.Errorf("CoinSettings 仍包含已移除的币种 BTC")
+	}
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	path := writeFile(t, baseFile+`
+unknown_field: 1
+retry_count: many
+window_rows: -1
+coins:
+  - name: BTC
+  - name: NOPE
+`)
+	t.Setenv("PRICE_RANGE_RATIO", "wide")
+
+	var checked []string
+	check := func(coins []string) ([]string, error) {
+		checked = coins
+		return []string{"NOPE"}, nil
+	}
+	_, err := Load(path, check)
+	if err == nil {
+		t.Fatalf("Load() 应返回错误")
+	}
+	for _, want := range []string{"unknown_field", "`many`", "PRICE_RANGE_RATIO", "window_rows", "Hyperliquid 未上线币种 NOPE"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误中缺少 %q:\n%v", want, err)
+		}
+	}
+	if strings.Join(checked, ",") != "BTC,NOPE" {
+		t.Errorf("检查的币种 = %v，期望 [BTC NOPE]", checked)
+	}
+}
+
+func TestLoadReportsSyntaxErrorWithEnvErrors(t *testing.T) {
+	path := writeFile(t, "telegram: [\n")
+	t.Setenv("PRICE_RANGE_RATIO", "wide")
+
+	_, err := Load(path, nil)
+	if err == nil {
+		t.Fatalf("Load() 应返回错误")
+	}
+	for _, want := range []string{"解析配置文件", "PRICE_RANGE_RATIO"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误中缺少 %q:\n%v", want, err)
+		}
+	}
+}
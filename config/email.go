@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// SMTP 连接的加密方式
+const (
+	EmailTLSStartTLS = "starttls" // 明文连接后升级为 TLS（通常为 587 端口）
+	EmailTLSImplicit = "tls"      // 直接建立 TLS 连接（通常为 465 端口）
+	EmailTLSNone     = "none"     // 不加密，仅用于本地测试
+)
+
+const (
+	defaultSMTPPort       = 587
+	defaultDigestSchedule = "0 0 9 * * *"
+)
+
+// EmailConfig 邮件日报配置，配置 host 和收件人后启用
+type EmailConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"`
+	Username string   `yaml:"username"` // 为空时不进行 SMTP 认证
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	TLS      string   `yaml:"tls"`      // starttls（默认）、tls 或 none
+	Schedule string   `yaml:"schedule"` // 日报推送计划，格式同 Config.Schedule，默认每天 09:00
+	Subject  string   `yaml:"subject"`  // 邮件标题，发送时附加日期；为空时按 Config.Locale 使用默认标题
+}
+
+// Enabled 判断是否启用邮件日报，只配置了部分 SMTP 参数时同样视为启用并在校验时报错
+func (e EmailConfig) Enabled() bool {
+	return e.Host != "" || len(e.To) > 0
+}
+
+// ParseList 解析逗号分隔的列表，去除空白和空项
+func ParseList(raw string) []string {
+	var items []string
+	for _, part := range strings.Split(raw, ",") {
+		if item := strings.TrimSpace(part); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// validate 校验邮件日报配置
+func (e EmailConfig) validate(interval time.Duration, timezone string) []error {
+	var errs []error
+	addErr := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if e.Host == "" {
+		addErr("邮件日报缺少 SMTP_HOST（email.host）")
+	}
+	if e.Port <= 0 || e.Port > 65535 {
+		addErr("SMTP_PORT 无效: %d", e.Port)
+	}
+	switch e.TLS {
+	case EmailTLSStartTLS, EmailTLSImplicit, EmailTLSNone:
+	default:
+		addErr("SMTP_TLS 无效: %q（可选 starttls、tls、none）", e.TLS)
+	}
+	if (e.Username == "") != (e.Password == "") {
+		addErr("SMTP_USERNAME 和 SMTP_PASSWORD 需要同时配置")
+	}
+	if _, err := mail.ParseAddress(e.From); err != nil {
+		addErr("SMTP_FROM 发件人地址无效: %q", e.From)
+	}
+	if len(e.To) == 0 {
+		addErr("邮件日报缺少收件人 SMTP_TO（email.to）")
+	}
+	for _, to := range e.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			addErr("SMTP_TO 收件人地址无效: %q", to)
+		}
+	}
+	if err := validateSchedule("email", e.Schedule, interval, timezone); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
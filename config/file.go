@@ -0,0 +1,221 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig 配置文件（YAML）结构，字段为空时保留默认值，环境变量优先级更高
+type fileConfig struct {
+	Telegram struct {
+		Token       string        `yaml:"token"`
+		ChatID      string        `yaml:"chat_id"`
+		ThreadID    int64         `yaml:"thread_id"`
+		Proxy       string        `yaml:"proxy"`
+		APIURL      string        `yaml:"api_url"`
+		AlertChatID string        `yaml:"alert_chat_id"`
+		UpdateMode  string        `yaml:"update_mode"`
+		MessageMode string        `yaml:"message_mode"`
+		Webhook     WebhookConfig `yaml:"webhook"`
+	} `yaml:"telegram"`
+
+	Mongo struct {
+		URI        string `yaml:"uri"`
+		DB         string `yaml:"db"`
+		Collection string `yaml:"collection"`
+	} `yaml:"mongo"`
+
+	Interval        string  `yaml:"interval"`
+	Schedule        string  `yaml:"schedule"`
+	Timezone        string  `yaml:"timezone"`
+	RetryCount      int     `yaml:"retry_count"`
+	RetryDelay      string  `yaml:"retry_delay"`
+	MaxJobs         int     `yaml:"max_concurrent_jobs"`
+	JobTimeout      string  `yaml:"job_timeout"`
+	WSPingInterval  string  `yaml:"ws_ping_interval"`
+	WSIdleTimeout   string  `yaml:"ws_idle_timeout"`
+	PriceStaleAfter string  `yaml:"price_stale_after"`
+	PriceFallback   bool    `yaml:"price_rest_fallback"`
+	PriceRangeRatio float64 `yaml:"price_range_ratio"`
+	WindowRows      int     `yaml:"window_rows"`
+	Chart           string  `yaml:"chart"`
+	TemplatesDir    string  `yaml:"templates_dir"`
+	Locale          string  `yaml:"locale"`
+
+	Coins []fileCoin   `yaml:"coins"`
+	Chats []ChatConfig `yaml:"chats"`
+	Email EmailConfig  `yaml:"email"`
+}
+
+// fileCoin 配置文件中的币种配置
+type fileCoin struct {
+	Name            string  `yaml:"name"`
+	BinSize         float64 `yaml:"bin_size"`
+	PriceRangeRatio float64 `yaml:"price_range_ratio"`
+	WindowRows      int     `yaml:"window_rows"`
+	Precision       *int    `yaml:"precision"`
+	Schedule        string  `yaml:"schedule"`
+	StaleAfter      string  `yaml:"stale_after"`
+}
+
+// loadFile 读取配置文件并写入 cfg，未知字段和字段取值错误收集后返回；
+// 文件无法读取或 YAML 语法错误时不写入 cfg
+func loadFile(path string, cfg *Config) []error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return []error{fmt.Errorf("读取配置文件失败: %v", err)}
+	}
+
+	var errs []error
+	var fc fileConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&fc); err != nil {
+		// 未知字段、类型错误时其余字段已正常解析，继续写入并一起报告
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return []error{fmt.Errorf("解析配置文件 %s 失败: %v", path, err)}
+		}
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, fmt.Errorf("配置文件 %s: %s", path, msg))
+		}
+	}
+
+	cfg.TelegramToken = fc.Telegram.Token
+	cfg.TelegramChatID = fc.Telegram.ChatID
+	cfg.TelegramThreadID = fc.Telegram.ThreadID
+	cfg.TelegramProxy = fc.Telegram.Proxy
+	cfg.TelegramAPIURL = fc.Telegram.APIURL
+	cfg.AlertChatID = fc.Telegram.AlertChatID
+	if fc.Telegram.UpdateMode != "" {
+		cfg.TelegramUpdateMode = fc.Telegram.UpdateMode
+	}
+	if fc.Telegram.MessageMode != "" {
+		cfg.MessageMode = fc.Telegram.MessageMode
+	}
+	listen := cfg.Webhook.Listen
+	cfg.Webhook = fc.Telegram.Webhook
+	if cfg.Webhook.Listen == "" {
+		cfg.Webhook.Listen = listen
+	}
+	cfg.MongoURI = fc.Mongo.URI
+	cfg.MongoDB = fc.Mongo.DB
+	cfg.MongoCollection = fc.Mongo.Collection
+
+	if fc.Interval != "" {
+		interval, err := ParseInterval(fc.Interval)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("interval 配置无效: %v", err))
+		} else {
+			cfg.Interval = interval
+		}
+	}
+	cfg.Schedule = fc.Schedule
+	cfg.Timezone = fc.Timezone
+	if fc.RetryCount > 0 {
+		cfg.RetryCount = fc.RetryCount
+	}
+	if fc.RetryDelay != "" {
+		delay, err := ParseInterval(fc.RetryDelay)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("retry_delay 配置无效: %v", err))
+		} else {
+			cfg.RetryDelay = delay
+		}
+	}
+	if fc.MaxJobs != 0 {
+		cfg.MaxConcurrentJobs = fc.MaxJobs
+	}
+	if fc.JobTimeout != "" {
+		timeout, err := ParseInterval(fc.JobTimeout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("job_timeout 配置无效: %v", err))
+		} else {
+			cfg.JobTimeout = timeout
+		}
+	}
+	if fc.WSPingInterval != "" {
+		interval, err := ParseInterval(fc.WSPingInterval)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ws_ping_interval 配置无效: %v", err))
+		} else {
+			cfg.WSPingInterval = interval
+		}
+	}
+	if fc.WSIdleTimeout != "" {
+		timeout, err := ParseInterval(fc.WSIdleTimeout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ws_idle_timeout 配置无效: %v", err))
+		} else {
+			cfg.WSIdleTimeout = timeout
+		}
+	}
+	if fc.PriceStaleAfter != "" {
+		staleAfter, err := ParseInterval(fc.PriceStaleAfter)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("price_stale_after 配置无效: %v", err))
+		} else {
+			cfg.PriceStaleAfter = staleAfter
+		}
+	}
+	cfg.PriceRESTFallback = fc.PriceFallback
+	if fc.PriceRangeRatio != 0 {
+		cfg.PriceRangeRatio = fc.PriceRangeRatio
+	}
+	if fc.WindowRows != 0 {
+		cfg.WindowRows = fc.WindowRows
+	}
+	if fc.Chart != "" {
+		cfg.Chart = fc.Chart
+	}
+	cfg.TemplatesDir = fc.TemplatesDir
+	if fc.Locale != "" {
+		cfg.Locale = fc.Locale
+	}
+
+	for _, coin := range fc.Coins {
+		settings := CoinSettings{
+			BinSize:         coin.BinSize,
+			PriceRangeRatio: coin.PriceRangeRatio,
+			WindowRows:      coin.WindowRows,
+			Precision:       AutoPrecision,
+			Schedule:        coin.Schedule,
+		}
+		if settings.BinSize == 0 {
+			settings.BinSize = defaultBinSizes[coin.Name]
+		}
+		if coin.Precision != nil {
+			settings.Precision = *coin.Precision
+		}
+		if coin.StaleAfter != "" {
+			staleAfter, err := ParseInterval(coin.StaleAfter)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s stale_after 配置无效: %v", coin.Name, err))
+			} else {
+				settings.StaleAfter = staleAfter
+			}
+		}
+		cfg.Coins = append(cfg.Coins, coin.Name)
+		cfg.CoinSettings[coin.Name] = settings
+	}
+	cfg.Chats = fc.Chats
+
+	// 邮件日报未配置的项保留默认值
+	email := fc.Email
+	if email.Port == 0 {
+		email.Port = cfg.Email.Port
+	}
+	if email.TLS == "" {
+		email.TLS = cfg.Email.TLS
+	}
+	if email.Schedule == "" {
+		email.Schedule = cfg.Email.Schedule
+	}
+	cfg.Email = email
+
+	return errs
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Changes 两次加载的配置之间的差异
+type Changes struct {
+	AddedCoins      []string // 新增的币种，需要订阅
+	RemovedCoins    []string // 移除的币种，需要取消订阅
+	ScheduleChanged bool     // 定时任务需要重新注册
+	ChatsChanged    bool     // 推送目标变化
+	SettingsChanged bool     // 统计与展示参数变化
+	RestartRequired []string // 修改后需要重启才能生效的配置项
+}
+
+// Diff 比较新旧配置
+func Diff(old, updated *Config) Changes {
+	var changes Changes
+
+	for _, coin := range updated.Coins {
+		if !old.HasCoin(coin) {
+			changes.AddedCoins = append(changes.AddedCoins, coin)
+		}
+	}
+	for _, coin := range old.Coins {
+		if !updated.HasCoin(coin) {
+			changes.RemovedCoins = append(changes.RemovedCoins, coin)
+		}
+	}
+
+	changes.ScheduleChanged = !reflect.DeepEqual(old.Jobs(), updated.Jobs())
+	changes.ChatsChanged = !reflect.DeepEqual(old.Chats, updated.Chats) || !reflect.DeepEqual(old.Email, updated.Email)
+	changes.SettingsChanged = old.PriceRangeRatio != updated.PriceRangeRatio ||
+		old.WindowRows != updated.WindowRows ||
+		old.TemplatesDir != updated.TemplatesDir ||
+		old.Locale != updated.Locale ||
+		old.RetryCount != updated.RetryCount ||
+		old.RetryDelay != updated.RetryDelay ||
+		old.MaxConcurrentJobs != updated.MaxConcurrentJobs ||
+		old.JobTimeout != updated.JobTimeout ||
+		old.WSPingInterval != updated.WSPingInterval ||
+		old.WSIdleTimeout != updated.WSIdleTimeout ||
+		old.PriceStaleAfter != updated.PriceStaleAfter ||
+		old.PriceRESTFallback != updated.PriceRESTFallback ||
+		old.AlertChatID != updated.AlertChatID ||
+		!reflect.DeepEqual(old.CoinSettings, updated.CoinSettings)
+
+	if old.TelegramToken != updated.TelegramToken {
+		changes.RestartRequired = append(changes.RestartRequired, "telegram.token")
+	}
+	if old.TelegramProxy != updated.TelegramProxy {
+		changes.RestartRequired = append(changes.RestartRequired, "telegram.proxy")
+	}
+	if old.TelegramAPIURL != updated.TelegramAPIURL {
+		changes.RestartRequired = append(changes.RestartRequired, "telegram.api_url")
+	}
+	if old.TelegramUpdateMode != updated.TelegramUpdateMode || old.Webhook != updated.Webhook {
+		changes.RestartRequired = append(changes.RestartRequired, "telegram.update_mode")
+	}
+	if old.MongoURI != updated.MongoURI || old.MongoDB != updated.MongoDB || old.MongoCollection != updated.MongoCollection {
+		changes.RestartRequired = append(changes.RestartRequired, "mongo")
+	}
+
+	return changes
+}
+
+// Empty 判断是否没有任何变化
+func (c Changes) Empty() bool {
+	return len(c.AddedCoins) == 0 && len(c.RemovedCoins) == 0 &&
+		!c.ScheduleChanged && !c.ChatsChanged && !c.SettingsChanged &&
+		len(c.RestartRequired) == 0
+}
+
+func (c Changes) String() string {
+	var parts []string
+	if len(c.AddedCoins) > 0 {
+		parts = append(parts, "新增币种 "+strings.Join(c.AddedCoins, ","))
+	}
+	if len(c.RemovedCoins) > 0 {
+		parts = append(parts, "移除币种 "+strings.Join(c.RemovedCoins, ","))
+	}
+	if c.ScheduleChanged {
+		parts = append(parts, "定时任务")
+	}
+	if c.ChatsChanged {
+		parts = append(parts, "推送目标")
+	}
+	if c.SettingsChanged {
+		parts = append(parts, "统计参数")
+	}
+	if len(c.RestartRequired) > 0 {
+		parts = append(parts, fmt.Sprintf("需重启生效: %s", strings.Join(c.RestartRequired, ",")))
+	}
+	if len(parts) == 0 {
+		return "无变化"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Watch 定期检查配置文件的修改时间，文件变化时向返回的通道发送通知；path 为空时返回 nil
+func Watch(ctx context.Context, path string, interval time.Duration) <-chan struct{} {
+	if path == "" {
+		return nil
+	}
+
+	changed := make(chan struct{}, 1)
+	go func() {
+		lastMod := modTime(path)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				mod := modTime(path)
+				if mod.Equal(lastMod) {
+					continue
+				}
+				lastMod = mod
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changed
+}
+
+// modTime 返回文件修改时间，文件不存在时返回零值
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+// testConfig 返回两个币种、一个推送目标的配置
+func testConfig() *Config {
+	return &Config{
+		TelegramToken:   "TOKEN",
+		MongoURI:        "mongodb://localhost:27017",
+		Coins:           []string{"BTC", "ETH"},
+		Chats:           []ChatConfig{{ID: "-100"}},
+		Interval:        time.Hour,
+		RetryCount:      3,
+		PriceRangeRatio: 0.05,
+		WindowRows:      20,
+		CoinSettings: map[string]CoinSettings{
+			"BTC": {BinSize: 100},
+			"ETH": {BinSize: 10},
+		},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(c *Config)
+		want   Changes
+	}{
+		{
+			name:   "无变化",
+			update: func(c *Config) {},
+		},
+		{
+			name: "增删币种",
+			update: func(c *Config) {
+				c.Coins = []string{"BTC", "SOL"}
+				c.CoinSettings = map[string]CoinSettings{"BTC": {BinSize: 100}, "SOL": {BinSize: 1}}
+			},
+			want: Changes{AddedCoins: []string{"SOL"}, RemovedCoins: []string{"ETH"}, ScheduleChanged: true, SettingsChanged: true},
+		},
+		{
+			name:   "币种推送计划",
+			update: func(c *Config) { c.CoinSettings["BTC"] = CoinSettings{BinSize: 100, Schedule: "30m"} },
+			want:   Changes{ScheduleChanged: true, SettingsChanged: true},
+		},
+		{
+			name:   "推送目标",
+			update: func(c *Config) { c.Chats = []ChatConfig{{ID: "-100", Format: FormatText}} },
+			want:   Changes{ChatsChanged: true},
+		},
+		{
+			name:   "推送目标单独的推送计划",
+			update: func(c *Config) { c.Chats = []ChatConfig{{ID: "-100", Schedule: "15m"}} },
+			want:   Changes{ScheduleChanged: true, ChatsChanged: true},
+		},
+		{
+			name:   "统计参数",
+			update: func(c *Config) { c.WindowRows = 30 },
+			want:   Changes{SettingsChanged: true},
+		},
+		{
+			name: "需重启的配置",
+			update: func(c *Config) {
+				c.TelegramToken = "OTHER"
+				c.MongoURI = "mongodb://other:27017"
+			},
+			want: Changes{RestartRequired: []string{"telegram.token", "mongo"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := testConfig()
+			tt.update(updated)
+
+			got := Diff(testConfig(), updated)
+			if got.String() != tt.want.String() {
+				t.Errorf("Diff() = %s，期望 %s", got, tt.want)
+			}
+			if got.Empty() != (tt.want.String() == "无变化") {
+				t.Errorf("Empty() = %v，变化为 %s", got.Empty(), got)
+			}
+		})
+	}
+}
+
+func TestDiffIgnoresCoinOrder(t *testing.T) {
+	updated := testConfig()
+	updated.Coins = []string{"ETH", "BTC"}
+
+	changes := Diff(testConfig(), updated)
+	if len(changes.AddedCoins) > 0 || len(changes.RemovedCoins) > 0 {
+		t.Errorf("调整币种顺序不应增删币种: %s", changes)
+	}
+}
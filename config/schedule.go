@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ScheduleParser 解析推送计划的 cron 解析器，秒字段可选，支持 @every、@daily 等描述符和 CRON_TZ= 前缀
+var ScheduleParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// ScheduleFor 返回币种的 cron 表达式：优先使用币种单独配置，其次全局 Schedule，最后使用 Interval
+func (c *Config) ScheduleFor(coin string) string {
+	schedule := c.Schedule
+	if settings, ok := c.CoinSettings[coin]; ok && settings.Schedule != "" {
+		schedule = settings.Schedule
+	}
+	return ScheduleSpec(schedule, c.Interval, c.Timezone)
+}
+
+// Job 一个定时推送任务：币种按同一推送计划发送到一组目标；Digest 为 true 时表示所有币种的邮件日报
+type Job struct {
+	Coin   string
+	Spec   string
+	Digest bool
+}
+
+// Name 返回用于日志的任务名称
+func (j Job) Name() string {
+	if j.Digest {
+		return "邮件日报"
+	}
+	return j.Coin
+}
+
+// ChatScheduleFor 返回推送目标接收某个币种的 cron 表达式，目标单独配置的推送计划优先
+func (c *Config) ChatScheduleFor(coin string, chat ChatConfig) string {
+	if chat.Schedule != "" {
+		return ScheduleSpec(chat.Schedule, c.Interval, c.Timezone)
+	}
+	return c.ScheduleFor(coin)
+}
+
+// Jobs 根据路由表生成定时任务，同一币种推送计划相同的目标共用一个任务。
+// 每个币种总会按默认推送计划注册一个任务，用于向订阅用户私信推送
+func (c *Config) Jobs() []Job {
+	var jobs []Job
+	seen := make(map[Job]bool)
+	add := func(job Job) {
+		if !seen[job] {
+			seen[job] = true
+			jobs = append(jobs, job)
+		}
+	}
+	for _, coin := range c.Coins {
+		add(Job{Coin: coin, Spec: c.ScheduleFor(coin)})
+		for _, chat := range c.ChatsFor(coin) {
+			add(Job{Coin: coin, Spec: c.ChatScheduleFor(coin, chat)})
+		}
+	}
+	if c.Email.Enabled() {
+		add(Job{Spec: c.DigestSchedule(), Digest: true})
+	}
+	return jobs
+}
+
+// DigestSchedule 返回邮件日报的 cron 表达式
+func (c *Config) DigestSchedule() string {
+	return ScheduleSpec(c.Email.Schedule, c.Interval, c.Timezone)
+}
+
+// IsSubscriberJob 判断定时任务是否按币种默认推送计划执行，订阅用户只在这些任务中接收推送
+func (c *Config) IsSubscriberJob(job Job) bool {
+	return !job.Digest && job.Spec == c.ScheduleFor(job.Coin)
+}
+
+// ChatsForJob 返回定时任务需要推送的目标
+func (c *Config) ChatsForJob(job Job) []ChatConfig {
+	var chats []ChatConfig
+	for _, chat := range c.ChatsFor(job.Coin) {
+		if c.ChatScheduleFor(job.Coin, chat) == job.Spec {
+			chats = append(chats, chat)
+		}
+	}
+	return chats
+}
+
+// ScheduleSpec 将推送计划转换为 cron 表达式：持续时间按 @every 处理，其余视为 cron 表达式并附加时区
+func ScheduleSpec(schedule string, interval time.Duration, timezone string) string {
+	schedule = strings.TrimSpace(schedule)
+	if schedule == "" {
+		return "@every " + interval.String()
+	}
+	if d, err := ParseInterval(schedule); err == nil {
+		return "@every " + d.String()
+	}
+	if timezone != "" && !strings.HasPrefix(schedule, "TZ=") && !strings.HasPrefix(schedule, "CRON_TZ=") {
+		return "CRON_TZ=" + timezone + " " + schedule
+	}
+	return schedule
+}
+
+// validateSchedule 校验推送计划能否被解析
+func validateSchedule(name, schedule string, interval time.Duration, timezone string) error {
+	if d, err := ParseInterval(strings.TrimSpace(schedule)); err == nil && d < time.Second {
+		return fmt.Errorf("%s 推送间隔不能小于 1s，当前为 %v", name, d)
+	}
+	spec := ScheduleSpec(schedule, interval, timezone)
+	if _, err := ScheduleParser.Parse(spec); err != nil {
+		return fmt.Errorf("%s 推送计划 %q 无效: %v", name, spec, err)
+	}
+	return nil
+}
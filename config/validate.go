@@ -0,0 +1,368 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"hyper-notify-bot/i18n"
+)
+
+// webhookSecretPattern Telegram 允许的 secret_token 字符
+var webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// coinPattern 合法的币种名称，兼容现货币种（如 @107、PURR/USDC）
+var coinPattern = regexp.MustCompile(`^[A-Za-z0-9@/:._-]+$`)
+
+// Validate 校验配置，返回所有发现的问题
+func (c *Config) Validate() []error {
+	var errs []error
+	addErr := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.TelegramToken == "" {
+		addErr("缺少 Telegram Bot Token（TELEGRAM_BOT_TOKEN 或 telegram.token）")
+	}
+	if len(c.Chats) == 0 {
+		addErr("缺少推送目标（TELEGRAM_CHAT_ID、telegram.chat_id 或 chats）")
+	}
+	if c.TelegramAPIURL != "" {
+		if apiURL, err := url.Parse(c.TelegramAPIURL); err != nil || (apiURL.Scheme != "http" && apiURL.Scheme != "https") || apiURL.Host == "" {
+			addErr("TELEGRAM_API_URL 必须为 http:// 或 https:// 开头的完整地址")
+		}
+	}
+	switch c.TelegramUpdateMode {
+	case UpdateModePolling, UpdateModeOff:
+	case UpdateModeWebhook:
+		errs = append(errs, c.Webhook.validate()...)
+	default:
+		addErr("telegram.update_mode 无效: %q（可选 polling、webhook、off）", c.TelegramUpdateMode)
+	}
+	if c.MessageMode != MessageModePost && c.MessageMode != MessageModeEdit {
+		addErr("telegram.message_mode 无效: %q（可选 post、edit）", c.MessageMode)
+	}
+	if !validChart(c.Chart) {
+		addErr("chart 无效: %q（可选 off、with、only）", c.Chart)
+	}
+	if !i18n.Supported(c.Locale) {
+		addErr("locale 无效: %q（可选 %s）", c.Locale, strings.Join(i18n.Languages(), "、"))
+	}
+	if c.TemplatesDir != "" {
+		if info, err := os.Stat(c.TemplatesDir); err != nil || !info.IsDir() {
+			addErr("TEMPLATES_DIR 不是有效的目录: %q", c.TemplatesDir)
+		}
+	}
+	if c.TelegramProxy != "" {
+		proxyURL, err := url.Parse(c.TelegramProxy)
+		if err != nil {
+			addErr("TELEGRAM_PROXY 无效: %v", err)
+		} else if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" && proxyURL.Scheme != "socks5" {
+			addErr("TELEGRAM_PROXY 不支持的协议 %q（仅支持 http、https、socks5）", proxyURL.Scheme)
+		}
+	}
+
+	if c.MongoURI == "" {
+		addErr("缺少 MongoDB 连接地址（MONGO_URI 或 mongo.uri）")
+	} else if mongoURL, err := url.Parse(c.MongoURI); err != nil {
+		addErr("MONGO_URI 无效: %v", err)
+	} else if mongoURL.Scheme != "mongodb" && mongoURL.Scheme != "mongodb+srv" {
+		addErr("MONGO_URI 协议必须为 mongodb:// 或 mongodb+srv://")
+	}
+	if c.MongoDB == "" {
+		addErr("缺少 MongoDB 数据库名（MONGO_DB 或 mongo.db）")
+	}
+
+	if c.Interval < time.Second {
+		addErr("定时任务间隔不能小于 1s，当前为 %v", c.Interval)
+	}
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			addErr("时区 %q 无效: %v", c.Timezone, err)
+		}
+	}
+	if err := validateSchedule("全局", c.Schedule, c.Interval, c.Timezone); err != nil {
+		errs = append(errs, err)
+	}
+	if c.RetryCount <= 0 {
+		addErr("重试次数必须大于 0，当前为 %d", c.RetryCount)
+	}
+	if c.MaxConcurrentJobs <= 0 {
+		addErr("max_concurrent_jobs 必须大于 0，当前为 %d", c.MaxConcurrentJobs)
+	}
+	if c.JobTimeout <= 0 {
+		addErr("job_timeout 必须大于 0，当前为 %v", c.JobTimeout)
+	}
+	if c.WSPingInterval <= 0 {
+		addErr("ws_ping_interval 必须大于 0，当前为 %v", c.WSPingInterval)
+	}
+	// 空闲超时不大于 ping 间隔时，正常的连接也会因等待 pong 而超时
+	if c.WSIdleTimeout <= c.WSPingInterval {
+		addErr("ws_idle_timeout 必须大于 ws_ping_interval (%v)，当前为 %v", c.WSPingInterval, c.WSIdleTimeout)
+	}
+	if c.PriceStaleAfter <= 0 {
+		addErr("price_stale_after 必须大于 0，当前为 %v", c.PriceStaleAfter)
+	}
+	if c.PriceRangeRatio <= 0 || c.PriceRangeRatio >= 1 {
+		addErr("price_range_ratio 必须在 (0, 1) 之间，当前为 %v", c.PriceRangeRatio)
+	}
+	if c.WindowRows <= 0 {
+		addErr("window_rows 必须大于 0，当前为 %d", c.WindowRows)
+	}
+
+	seen := make(map[string]bool)
+	for _, coin := range c.Coins {
+		if !coinPattern.MatchString(coin) {
+			addErr("币种名称无效: %q", coin)
+		}
+		if seen[coin] {
+			addErr("币种重复: %s", coin)
+		}
+		seen[coin] = true
+	}
+
+	for coin, settings := range c.CoinSettings {
+		if !seen[coin] {
+			addErr("未知币种 %s：存在单独配置但不在币种列表中", coin)
+			continue
+		}
+		if settings.BinSize < 0 {
+			addErr("%s 分箱宽度不能为负数", coin)
+		}
+		if settings.PriceRangeRatio < 0 || settings.PriceRangeRatio >= 1 {
+			addErr("%s price_range_ratio 必须在 [0, 1) 之间", coin)
+		}
+		if settings.WindowRows < 0 {
+			addErr("%s window_rows 不能为负数", coin)
+		}
+		if settings.Precision < AutoPrecision || settings.Precision > 8 {
+			addErr("%s 小数位数必须在 0-8 之间", coin)
+		}
+		if settings.StaleAfter < 0 {
+			addErr("%s stale_after 不能为负数", coin)
+		}
+		if settings.Schedule != "" {
+			if err := validateSchedule(coin, settings.Schedule, c.Interval, c.Timezone); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if c.Email.Enabled() {
+		errs = append(errs, c.Email.validate(c.Interval, c.Timezone)...)
+	}
+
+	for i, chat := range c.Chats {
+		switch chat.Type {
+		case RouteTelegram:
+			if chat.ID == "" {
+				addErr("chats[%d] 缺少 id", i)
+			}
+		case RouteDiscord, RouteSlack, RouteWebhook:
+			if u, err := url.Parse(chat.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				addErr("chats[%d] %s 渠道需要配置 http:// 或 https:// 开头的 url", i, chat.Type)
+			}
+			if chat.Mode == MessageModeEdit {
+				addErr("chats[%d] %s 渠道不支持 edit 推送方式", i, chat.Type)
+			}
+		default:
+			addErr("chats[%d] type 无效: %q（可选 telegram、discord、slack、webhook）", i, chat.Type)
+		}
+		if chat.ThreadID < 0 {
+			addErr("chats[%d] thread_id 不能为负数", i)
+		}
+		switch {
+		case chat.Format == FormatMarkdownV2 && chat.Type != RouteTelegram:
+			addErr("chats[%d] %s 渠道不支持 markdownv2 格式（可选 html、text）", i, chat.Type)
+		case chat.Format != FormatHTML && chat.Format != FormatMarkdownV2 && chat.Format != FormatText:
+			addErr("chats[%d] format 无效: %q（可选 html、markdownv2、text）", i, chat.Format)
+		}
+		if chat.Mode != MessageModePost && chat.Mode != MessageModeEdit {
+			addErr("chats[%d] mode 无效: %q（可选 post、edit）", i, chat.Mode)
+		}
+		if !validChart(chat.Chart) {
+			addErr("chats[%d] chart 无效: %q（可选 off、with、only）", i, chat.Chart)
+		}
+		if !i18n.Supported(chat.Locale) {
+			addErr("chats[%d] locale 无效: %q（可选 %s）", i, chat.Locale, strings.Join(i18n.Languages(), "、"))
+		}
+		if chat.Schedule != "" {
+			if err := validateSchedule(fmt.Sprintf("chats[%d]", i), chat.Schedule, c.Interval, c.Timezone); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		for _, coin := range chat.Coins {
+			if !seen[coin] {
+				addErr("chats[%d] 引用了未知币种 %s", i, coin)
+			}
+		}
+	}
+
+	return errs
+}
+
+// checkCoins 确认 Hyperliquid 已上线配置的币种，格式无效的币种已由 Validate 报告，不再查询
+func (c *Config) checkCoins(check CoinChecker) []error {
+	var coins []string
+	for _, coin := range c.Coins {
+		if coinPattern.MatchString(coin) {
+			coins = append(coins, coin)
+		}
+	}
+	if len(coins) == 0 {
+		return nil
+	}
+
+	unknown, err := check(coins)
+	if err != nil {
+		return []error{fmt.Errorf("查询币种列表失败: %v", err)}
+	}
+	var errs []error
+	for _, coin := range unknown {
+		errs = append(errs, fmt.Errorf("Hyperliquid 未上线币种 %s", coin))
+	}
+	return errs
+}
+
+// validate 校验 webhook 配置
+func (w WebhookConfig) validate() []error {
+	var errs []error
+	if w.URL == "" {
+		errs = append(errs, fmt.Errorf("webhook 模式缺少 TELEGRAM_WEBHOOK_URL（telegram.webhook.url）"))
+	} else if u, err := url.Parse(w.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("TELEGRAM_WEBHOOK_URL 必须为 https:// 开头的完整地址"))
+	}
+	if !webhookSecretPattern.MatchString(w.Secret) {
+		errs = append(errs, fmt.Errorf("TELEGRAM_WEBHOOK_SECRET 必须为 1-256 位字母、数字、_ 或 -"))
+	}
+	if w.Listen == "" {
+		errs = append(errs, fmt.Errorf("webhook 模式缺少监听地址 TELEGRAM_WEBHOOK_LISTEN"))
+	}
+	if (w.CertFile == "") != (w.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("TELEGRAM_WEBHOOK_CERT 和 TELEGRAM_WEBHOOK_KEY 需要同时配置"))
+	}
+	return errs
+}
+
+// Summary 返回脱敏后的配置摘要，用于启动日志
+func (c *Config) Summary() string {
+	var b strings.Builder
+
+	file := c.File
+	if file == "" {
+		file = "(未使用，仅环境变量)"
+	}
+	fmt.Fprintf(&b, "  配置文件: %s\n", file)
+	fmt.Fprintf(&b, "  Telegram Token: %s\n", redactSecret(c.TelegramToken))
+	fmt.Fprintf(&b, "  Telegram 代理: %s\n", redactURL(c.TelegramProxy))
+	if c.TelegramAPIURL != "" {
+		fmt.Fprintf(&b, "  Telegram API: %s\n", redactURL(c.TelegramAPIURL))
+	}
+	fmt.Fprintf(&b, "  Telegram 命令: %s\n", c.TelegramUpdateMode)
+	if c.TelegramUpdateMode == UpdateModeWebhook {
+		tls := "否"
+		if c.Webhook.CertFile != "" {
+			tls = "是"
+		}
+		fmt.Fprintf(&b, "  Webhook: %s，监听 %s，HTTPS %s，secret %s\n",
+			redactURL(c.Webhook.URL), c.Webhook.Listen, tls, redactSecret(c.Webhook.Secret))
+	}
+	fmt.Fprintf(&b, "  MongoDB: %s (%s/%s)\n", redactURL(c.MongoURI), c.MongoDB, c.MongoCollection)
+	fmt.Fprintf(&b, "  推送计划: %s，重试 %d 次，间隔 %v\n", ScheduleSpec(c.Schedule, c.Interval, c.Timezone), c.RetryCount, c.RetryDelay)
+	fmt.Fprintf(&b, "  并发任务: %d，单任务超时 %v\n", c.MaxConcurrentJobs, c.JobTimeout)
+	fmt.Fprintf(&b, "  WebSocket 心跳: 每 %v ping，%v 无消息重连\n", c.WSPingInterval, c.WSIdleTimeout)
+	fallback := "关闭"
+	if c.PriceRESTFallback {
+		fallback = "开启"
+	}
+	alert := "仅日志"
+	if c.AlertChatID != "" {
+		alert = c.AlertChatID
+	}
+	fmt.Fprintf(&b, "  Oracle 价格: 超过 %v 未更新视为过期，REST 回退 %s，告警 %s\n", c.PriceStaleAfter, fallback, alert)
+	fmt.Fprintf(&b, "  价格窗口: ±%.2f%%，展示 %d 行\n", c.PriceRangeRatio*100, c.WindowRows)
+	if c.TemplatesDir != "" {
+		fmt.Fprintf(&b, "  消息模板: %s\n", c.TemplatesDir)
+	}
+
+	for _, coin := range c.Coins {
+		settings := c.SettingsFor(coin)
+		binSize := "自动"
+		if settings.BinSize > 0 {
+			binSize = fmt.Sprintf("%v", settings.BinSize)
+		}
+		precision := "自动"
+		if settings.Precision != AutoPrecision {
+			precision = fmt.Sprintf("%d", settings.Precision)
+		}
+		fmt.Fprintf(&b, "  币种 %s: 分箱 %s，窗口 ±%.2f%%，%d 行，小数位 %s，推送计划 %s，价格过期 %v\n",
+			coin, binSize, settings.PriceRangeRatio*100, settings.WindowRows, precision, c.ScheduleFor(coin), settings.StaleAfter)
+	}
+
+	for _, chat := range c.Chats {
+		coins := "全部"
+		if len(chat.Coins) > 0 {
+			coins = strings.Join(chat.Coins, ",")
+		}
+		target := chat.ID
+		if chat.ThreadID != 0 {
+			target = fmt.Sprintf("%s#%d", chat.ID, chat.ThreadID)
+		}
+		if chat.Type != RouteTelegram {
+			target = chat.Type + " " + redactPath(chat.URL)
+		}
+		schedule := "跟随币种"
+		if chat.Schedule != "" {
+			schedule = ScheduleSpec(chat.Schedule, c.Interval, c.Timezone)
+		}
+		fmt.Fprintf(&b, "  推送目标 %s: %s，格式 %s，语言 %s，推送方式 %s，图表 %s，推送计划 %s\n", target, coins, chat.Format, chat.Locale, chat.Mode, chat.Chart, schedule)
+	}
+
+	if c.Email.Enabled() {
+		fmt.Fprintf(&b, "  邮件日报: %s:%d (%s)，用户 %s，密码 %s，%s -> %s，推送计划 %s\n",
+			c.Email.Host, c.Email.Port, c.Email.TLS, c.Email.Username, redactSecret(c.Email.Password),
+			c.Email.From, strings.Join(c.Email.To, ","), c.DigestSchedule())
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// redactPath 隐藏 URL 的路径和参数，Discord、Slack 的 Webhook 地址路径中包含密钥
+func redactPath(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return "****"
+	}
+	return u.Scheme + "://" + u.Host + "/****"
+}
+
+// validChart 判断图表发送方式是否有效
+func validChart(chart string) bool {
+	return chart == ChartOff || chart == ChartWith || chart == ChartOnly
+}
+
+// redactSecret 仅保留前 4 个字符
+func redactSecret(s string) string {
+	if s == "" {
+		return "(未设置)"
+	}
+	if len(s) <= 8 {
+		return "****"
+	}
+	return s[:4] + "****"
+}
+
+// redactURL 隐藏 URL 中的密码
+func redactURL(s string) string {
+	if s == "" {
+		return "(未设置)"
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "****"
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,76 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DashboardCollection 原地更新消息集合，记录每个推送目标、每个币种的 message_id
+const DashboardCollection = "dashboards"
+
+// dashboards 返回原地更新消息集合
+func (m *MongoDBClient) dashboards() *mongo.Collection {
+	return m.Database.Collection(DashboardCollection)
+}
+
+// dashboardFilter 按推送目标和币种定位记录
+func dashboardFilter(chatID string, threadID int64, coin string) bson.D {
+	return bson.D{
+		{Key: "chat_id", Value: chatID},
+		{Key: "thread_id", Value: threadID},
+		{Key: "coin", Value: coin},
+	}
+}
+
+// EnsureDashboardIndexes 创建原地更新消息集合的索引
+func (m *MongoDBClient) EnsureDashboardIndexes(ctx context.Context) error {
+	_, err := m.dashboards().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "chat_id", Value: 1}, {Key: "thread_id", Value: 1}, {Key: "coin", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("创建原地更新消息索引失败: %v", err)
+	}
+	return nil
+}
+
+// GetDashboard 返回推送目标上币种对应的消息，不存在时返回 nil
+func (m *MongoDBClient) GetDashboard(ctx context.Context, chatID string, threadID int64, coin string) (*Dashboard, error) {
+	var dashboard Dashboard
+	err := m.dashboards().FindOne(ctx, dashboardFilter(chatID, threadID, coin)).Decode(&dashboard)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询原地更新消息失败: %v", err)
+	}
+	return &dashboard, nil
+}
+
+// SaveDashboard 保存推送目标上币种对应的消息
+func (m *MongoDBClient) SaveDashboard(ctx context.Context, dashboard Dashboard) error {
+	if dashboard.UpdatedAt.IsZero() {
+		dashboard.UpdatedAt = time.Now()
+	}
+
+	filter := dashboardFilter(dashboard.ChatID, dashboard.ThreadID, dashboard.Coin)
+	_, err := m.dashboards().ReplaceOne(ctx, filter, dashboard, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("保存原地更新消息失败: %v", err)
+	}
+	return nil
+}
+
+// DeleteDashboard 删除推送目标上币种对应的消息记录
+func (m *MongoDBClient) DeleteDashboard(ctx context.Context, chatID string, threadID int64, coin string) error {
+	if _, err := m.dashboards().DeleteOne(ctx, dashboardFilter(chatID, threadID, coin)); err != nil {
+		return fmt.Errorf("删除原地更新消息失败: %v", err)
+	}
+	return nil
+}
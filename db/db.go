@@ -98,15 +98,12 @@ func (m *MongoDBClient) GetTableData(ctx context.Context) ([]TableRow, error) {
 	return results, nil
 }
 
-// GetPricePositionSummary 获取仓位汇总数据
-func (m *MongoDBClient) GetPricePositionSummary(ctx context.Context, coin string, min, max float64) ([]PositionResult, error) {
-	// 初始化一个带有几个元素的map
-	coinDiv := map[string]float32{
-		"BTC":  100,
-		"ETH":  10,
-		"SOL":  1,
-		"HYPE": 0.5,
+// GetPricePositionSummary 获取仓位汇总数据，按 binSize 宽度对价格分箱
+func (m *MongoDBClient) GetPricePositionSummary(ctx context.Context, coin string, min, max, binSize float64) ([]PositionResult, error) {
+	if binSize <= 0 {
+		return nil, fmt.Errorf("%s 分箱宽度无效: %v", coin, binSize)
 	}
+
 	// 创建聚合管道
 	pipeline := mongo.Pipeline{
 		// 1. 筛选价格区间
@@ -121,7 +118,7 @@ func (m *MongoDBClient) GetPricePositionSummary(ctx context.Context, coin string
 		{{"$addFields", bson.D{
 			{"bin", bson.D{
 				{"$trunc", bson.D{
-					{"$divide", bson.A{"$px", coinDiv[coin]}},
+					{"$divide", bson.A{"$px", binSize}},
 				}},
 			}},
 		}}},
@@ -149,7 +146,7 @@ func (m *MongoDBClient) GetPricePositionSummary(ctx context.Context, coin string
 		// 5. 转换为对象格式
 		{{"$project", bson.D{
 			{"bin", bson.D{
-				{"$multiply", bson.A{"$_id", coinDiv[coin]}},
+				{"$multiply", bson.A{"$_id", binSize}},
 			}},
 			{"positions", bson.D{
 				{"$arrayToObject", bson.D{
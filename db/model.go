@@ -1,6 +1,10 @@
 package mongodb
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 // TableRow 表示从MongoDB读取的数据结构
 type TableRow struct {
@@ -20,3 +24,21 @@ type PositionTotals struct {
 	Long  primitive.Decimal128 `bson:"Long"`
 	Short primitive.Decimal128 `bson:"Short"`
 }
+
+// Subscription 用户订阅的币种，定时推送时私信发送给用户
+type Subscription struct {
+	UserID    int64     `bson:"user_id"`
+	Username  string    `bson:"username,omitempty"`
+	Coin      string    `bson:"coin"`
+	Locale    string    `bson:"locale,omitempty"` // 私信使用的语言，为空时使用默认语言
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// Dashboard 原地更新模式下每个推送目标、每个币种固定的一条消息
+type Dashboard struct {
+	ChatID    string    `bson:"chat_id"`
+	ThreadID  int64     `bson:"thread_id"`
+	Coin      string    `bson:"coin"`
+	MessageID int64     `bson:"message_id"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
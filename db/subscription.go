@@ -0,0 +1,96 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SubscriptionCollection 用户订阅集合，与 *_positions 集合位于同一数据库
+const SubscriptionCollection = "subscriptions"
+
+// subscriptions 返回用户订阅集合
+func (m *MongoDBClient) subscriptions() *mongo.Collection {
+	return m.Database.Collection(SubscriptionCollection)
+}
+
+// EnsureSubscriptionIndexes 创建订阅集合的索引，同一用户对同一币种只保留一条订阅
+func (m *MongoDBClient) EnsureSubscriptionIndexes(ctx context.Context) error {
+	_, err := m.subscriptions().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "coin", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "coin", Value: 1}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("创建订阅索引失败: %v", err)
+	}
+	return nil
+}
+
+// AddSubscription 添加订阅，返回是否为新订阅
+func (m *MongoDBClient) AddSubscription(ctx context.Context, sub Subscription) (bool, error) {
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = time.Now()
+	}
+
+	filter := bson.D{{Key: "user_id", Value: sub.UserID}, {Key: "coin", Value: sub.Coin}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "username", Value: sub.Username}, {Key: "locale", Value: sub.Locale}}},
+		{Key: "$setOnInsert", Value: bson.D{{Key: "created_at", Value: sub.CreatedAt}}},
+	}
+	result, err := m.subscriptions().UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, fmt.Errorf("保存订阅失败: %v", err)
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// RemoveSubscription 取消订阅，返回是否存在该订阅
+func (m *MongoDBClient) RemoveSubscription(ctx context.Context, userID int64, coin string) (bool, error) {
+	result, err := m.subscriptions().DeleteOne(ctx, bson.D{{Key: "user_id", Value: userID}, {Key: "coin", Value: coin}})
+	if err != nil {
+		return false, fmt.Errorf("删除订阅失败: %v", err)
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// RemoveUserSubscriptions 删除用户的所有订阅，返回删除数量
+func (m *MongoDBClient) RemoveUserSubscriptions(ctx context.Context, userID int64) (int64, error) {
+	result, err := m.subscriptions().DeleteMany(ctx, bson.D{{Key: "user_id", Value: userID}})
+	if err != nil {
+		return 0, fmt.Errorf("删除订阅失败: %v", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// ListSubscriptions 返回用户的所有订阅
+func (m *MongoDBClient) ListSubscriptions(ctx context.Context, userID int64) ([]Subscription, error) {
+	return m.findSubscriptions(ctx, bson.D{{Key: "user_id", Value: userID}})
+}
+
+// SubscribersFor 返回订阅了币种的所有用户
+func (m *MongoDBClient) SubscribersFor(ctx context.Context, coin string) ([]Subscription, error) {
+	return m.findSubscriptions(ctx, bson.D{{Key: "coin", Value: coin}})
+}
+
+func (m *MongoDBClient) findSubscriptions(ctx context.Context, filter bson.D) ([]Subscription, error) {
+	cursor, err := m.subscriptions().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("查询订阅失败: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var subs []Subscription
+	if err := cursor.All(ctx, &subs); err != nil {
+		return nil, fmt.Errorf("解析订阅失败: %v", err)
+	}
+	return subs, nil
+}
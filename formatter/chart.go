@@ -0,0 +1,191 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"hyper-notify-bot/config"
+	mongodb "hyper-notify-bot/db"
+)
+
+// 图表布局（像素）
+const (
+	chartWidth     = 900
+	chartRowHeight = 14
+	chartHeader    = 56 // 标题区域高度
+	chartFooter    = 28 // 图例区域高度
+	chartLabelW    = 96 // 左侧价格标签宽度
+	chartPadding   = 16
+	// maxChartRows 图表最多展示的分箱数，以最接近 Oracle 价格的分箱为中心
+	maxChartRows = 120
+)
+
+// 图表配色
+var (
+	chartBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	chartText       = color.RGBA{0x21, 0x21, 0x21, 0xff}
+	chartGrid       = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	chartLong       = color.RGBA{0x26, 0xa6, 0x9a, 0xff}
+	chartShort      = color.RGBA{0xef, 0x53, 0x50, 0xff}
+	chartOracle     = color.RGBA{0xff, 0x98, 0x00, 0xff}
+	chartHighlight  = color.RGBA{0xff, 0xf3, 0xe0, 0xff}
+)
+
+// chartRow 图表中的一个分箱
+type chartRow struct {
+	bin, long, short float64
+}
+
+// RenderPositionChart 将仓位分布绘制为 PNG 横向条形图：每个价格分箱一行，Long 向右、Short 向左，
+// 并以横线标出 Oracle 价格。settings 需已通过 Resolve 补全
+func RenderPositionChart(data []mongodb.PositionResult, coin, oraclePrice string, longSz, shortSz float64, settings config.CoinSettings) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s 当前价格窗口内没有仓位数据", coin)
+	}
+
+	targetPrice, _ := strconv.ParseFloat(oraclePrice, 64)
+	showData, closestIndex := windowRows(data, closestBin(data, oraclePrice), maxChartRows)
+
+	// 价格从高到低排列，高价在上
+	rows := make([]chartRow, len(showData))
+	maxSize := 0.0
+	for i, row := range showData {
+		r := &rows[len(showData)-1-i]
+		r.bin, _ = strconv.ParseFloat(row.Bin.String(), 64)
+		r.long, _ = strconv.ParseFloat(row.Long.String(), 64)
+		r.short, _ = strconv.ParseFloat(row.Short.String(), 64)
+		maxSize = math.Max(maxSize, math.Max(math.Abs(r.long), math.Abs(r.short)))
+	}
+	if closestIndex >= 0 {
+		closestIndex = len(showData) - 1 - closestIndex
+	}
+
+	height := chartHeader + len(rows)*chartRowHeight + chartFooter
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, height))
+	fillRect(img, img.Bounds(), chartBackground)
+
+	plotLeft := chartLabelW
+	plotRight := chartWidth - chartPadding
+	plotTop := chartHeader
+	plotBottom := plotTop + len(rows)*chartRowHeight
+	center := (plotLeft + plotRight) / 2
+	halfWidth := float64(plotRight-center) - 2
+
+	// 标题
+	drawText(img, chartPadding, 22, chartText, fmt.Sprintf("%s Position Distribution", coin))
+	drawText(img, chartPadding, 42, chartText, fmt.Sprintf("Oracle %s   Long %.2f   Short %.2f   Bin %v",
+		oraclePrice, longSz, shortSz, settings.BinSize))
+
+	precision := strconv.Itoa(settings.Precision)
+	for i, row := range rows {
+		y := plotTop + i*chartRowHeight
+		if i == closestIndex {
+			fillRect(img, image.Rect(0, y, chartWidth, y+chartRowHeight), chartHighlight)
+		}
+		drawText(img, chartPadding, y+chartRowHeight-3, chartText, fmt.Sprintf("%."+precision+"f", row.bin))
+
+		if maxSize > 0 {
+			if w := int(math.Round(row.long / maxSize * halfWidth)); w > 0 {
+				fillRect(img, image.Rect(center+1, y+2, center+1+w, y+chartRowHeight-2), chartLong)
+			}
+			if w := int(math.Round(math.Abs(row.short) / maxSize * halfWidth)); w > 0 {
+				fillRect(img, image.Rect(center-w, y+2, center, y+chartRowHeight-2), chartShort)
+			}
+		}
+	}
+
+	// 中轴线
+	fillRect(img, image.Rect(center, plotTop, center+1, plotBottom), chartGrid)
+
+	// Oracle 价格线：按价格在相邻分箱间插值定位
+	if y, ok := oracleLineY(rows, targetPrice); ok {
+		y += plotTop
+		fillRect(img, image.Rect(plotLeft, y-1, plotRight, y+1), chartOracle)
+		label := "Oracle " + oraclePrice
+		drawText(img, plotRight-textWidth(label), y-3, chartOracle, label)
+	}
+
+	// 图例
+	legendY := plotBottom + chartFooter - 9
+	fillRect(img, image.Rect(chartPadding, legendY-9, chartPadding+12, legendY), chartShort)
+	drawText(img, chartPadding+18, legendY, chartText, "Short")
+	fillRect(img, image.Rect(chartPadding+70, legendY-9, chartPadding+82, legendY), chartLong)
+	drawText(img, chartPadding+88, legendY, chartText, "Long")
+	scale := fmt.Sprintf("max bin size %.2f", maxSize)
+	drawText(img, plotRight-textWidth(scale), legendY, chartText, scale)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("生成图表失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// oracleLineY 返回 Oracle 价格线相对绘图区顶部的纵坐标。rows 按价格从高到低排列，
+// 每行中心为 (i+0.5)*chartRowHeight，价格在相邻两行的实际分箱值之间线性插值，
+// 因此分箱不连续（中间缺少没有仓位的分箱）时也能正确定位；价格超出展示范围时返回 false
+func oracleLineY(rows []chartRow, price float64) (int, bool) {
+	if price <= 0 || len(rows) == 0 {
+		return 0, false
+	}
+	if len(rows) == 1 {
+		if price != rows[0].bin {
+			return 0, false
+		}
+		return int(math.Round(0.5 * chartRowHeight)), true
+	}
+	if price > rows[0].bin || price < rows[len(rows)-1].bin {
+		return 0, false
+	}
+	for i := 0; i < len(rows)-1; i++ {
+		hi, lo := rows[i].bin, rows[i+1].bin
+		if price > hi || price < lo {
+			continue
+		}
+		frac := 0.0
+		if hi > lo {
+			frac = (hi - price) / (hi - lo)
+		}
+		return int(math.Round((float64(i) + 0.5 + frac) * chartRowHeight)), true
+	}
+	return 0, false
+}
+
+// FormatChartCaption 按 f 的格式和 locale 语言渲染 caption 模板生成图表说明
+func FormatChartCaption(f Formatter, locale, coin, oraclePrice string, longSz, shortSz float64) string {
+	return FormatReportCaption(f, locale, NewReportView(nil, coin, oraclePrice, longSz, shortSz, config.CoinSettings{}))
+}
+
+// FormatReportCaption 按 f 的格式和 locale 语言使用 view 渲染 caption 模板
+func FormatReportCaption(f Formatter, locale string, view ReportView) string {
+	return render(f, locale, "caption", view)
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+// drawText 在 (x, y) 处绘制文字，y 为基线位置；内置字体仅支持 ASCII
+func drawText(img *image.RGBA, x, y int, c color.Color, text string) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+func textWidth(text string) int {
+	return font.MeasureString(basicfont.Face7x13, text).Ceil()
+}
@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// htmlPrePattern 匹配 <pre> 代码块，代码块内的换行保持原样
+var htmlPrePattern = regexp.MustCompile(`(?s)<pre>.*?</pre>`)
+
+// emailPreStyle 邮件客户端通常不加载外部样式，使用内联样式保证表格等宽对齐
+const emailPreStyle = `font-family:Menlo,Consolas,'Courier New',monospace;font-size:13px;line-height:1.4;` +
+	`background:#f6f8fa;border:1px solid #e1e4e8;border-radius:4px;padding:8px 12px;white-space:pre;overflow-x:auto;`
+
+// HTMLToEmail 将 FormatTableAsHTML 的输出转换为适合邮件客户端的完整 HTML 文档：
+// 代码块使用内联样式，代码块外的换行转换为 <br>
+func HTMLToEmail(title, s string) string {
+	var body strings.Builder
+	last := 0
+	for _, loc := range htmlPrePattern.FindAllStringIndex(s, -1) {
+		body.WriteString(strings.ReplaceAll(s[last:loc[0]], "\n", "<br>\n"))
+		body.WriteString(`<pre style="` + emailPreStyle + `">`)
+		body.WriteString(strings.TrimPrefix(s[loc[0]+len("<pre>"):loc[1]], "\n"))
+		last = loc[1]
+	}
+	body.WriteString(strings.ReplaceAll(s[last:], "\n", "<br>\n"))
+
+	return `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width,initial-scale=1">
+<title>` + html.EscapeString(title) + `</title>
+</head>
+<body style="margin:0;padding:16px;font-family:-apple-system,'Segoe UI',Helvetica,Arial,sans-serif;font-size:14px;color:#24292e;">
+<h2 style="margin:0 0 16px;font-size:18px;">` + html.EscapeString(title) + `</h2>
+` + body.String() + `
+</body>
+</html>
+`
+}
@@ -0,0 +1,91 @@
+package formatter
+
+import (
+	"html"
+	"strings"
+
+	"hyper-notify-bot/config"
+)
+
+// Formatter 消息格式的标记语法，负责排版和转义。所有方法的参数均为未转义的原始文本
+type Formatter interface {
+	// ParseMode 返回 Telegram 的 parse_mode，纯文本为空
+	ParseMode() string
+	// Escape 转义普通文本
+	Escape(s string) string
+	// Bold 加粗
+	Bold(s string) string
+	// Pre 等宽代码块
+	Pre(s string) string
+	// Link 超链接
+	Link(url, label string) string
+}
+
+// Content 可按不同格式和语言渲染的消息内容，各渠道选择自身支持的格式和推送目标的语言
+type Content func(f Formatter, locale string) string
+
+// 内置的消息格式
+var (
+	HTML       Formatter = htmlFormatter{}       // Telegram HTML 子集：b、pre、a
+	MarkdownV2 Formatter = markdownV2Formatter{} // Telegram MarkdownV2
+	Text       Formatter = textFormatter{}       // 纯文本
+)
+
+// ForFormat 返回推送目标消息格式对应的 Formatter，未知格式按 HTML 处理
+func ForFormat(format string) Formatter {
+	switch format {
+	case config.FormatMarkdownV2:
+		return MarkdownV2
+	case config.FormatText:
+		return Text
+	default:
+		return HTML
+	}
+}
+
+type htmlFormatter struct{}
+
+func (htmlFormatter) ParseMode() string      { return "HTML" }
+func (htmlFormatter) Escape(s string) string { return html.EscapeString(s) }
+func (htmlFormatter) Bold(s string) string   { return "<b>" + html.EscapeString(s) + "</b>" }
+func (htmlFormatter) Pre(s string) string    { return "<pre>" + html.EscapeString(s) + "</pre>" }
+
+func (htmlFormatter) Link(url, label string) string {
+	return `<a href="` + html.EscapeString(url) + `">` + html.EscapeString(label) + "</a>"
+}
+
+// markdownV2Escaper MarkdownV2 普通文本中需要转义的全部保留字符
+var markdownV2Escaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// markdownV2CodeEscaper 代码块内只需转义 ` 和 \
+var markdownV2CodeEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+
+// markdownV2URLEscaper 链接地址内只需转义 ) 和 \
+var markdownV2URLEscaper = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+
+type markdownV2Formatter struct{}
+
+func (markdownV2Formatter) ParseMode() string      { return "MarkdownV2" }
+func (markdownV2Formatter) Escape(s string) string { return markdownV2Escaper.Replace(s) }
+func (markdownV2Formatter) Bold(s string) string   { return "*" + markdownV2Escaper.Replace(s) + "*" }
+
+func (markdownV2Formatter) Pre(s string) string {
+	// ``` 后的第一行会被当作语言标识，内容另起一行
+	return "```\n" + markdownV2CodeEscaper.Replace(strings.TrimPrefix(s, "\n")) + "```"
+}
+
+func (markdownV2Formatter) Link(url, label string) string {
+	return "[" + markdownV2Escaper.Replace(label) + "](" + markdownV2URLEscaper.Replace(url) + ")"
+}
+
+type textFormatter struct{}
+
+func (textFormatter) ParseMode() string             { return "" }
+func (textFormatter) Escape(s string) string        { return s }
+func (textFormatter) Bold(s string) string          { return s }
+func (textFormatter) Pre(s string) string           { return s }
+func (textFormatter) Link(url, label string) string { return label + ": " + url }
@@ -0,0 +1,49 @@
+package formatter
+
+import "strings"
+
+// 其他渠道的消息格式
+var (
+	Markdown Formatter = markdownFormatter{} // CommonMark 风格 Markdown（Discord）
+	Slack    Formatter = slackFormatter{}    // Slack mrkdwn
+)
+
+// markdownEscaper 转义 Discord Markdown 的标记字符
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`,
+	">", `\>`, "#", `\#`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+)
+
+type markdownFormatter struct{}
+
+func (markdownFormatter) ParseMode() string      { return "" }
+func (markdownFormatter) Escape(s string) string { return markdownEscaper.Replace(s) }
+func (markdownFormatter) Bold(s string) string   { return "**" + markdownEscaper.Replace(s) + "**" }
+
+// Pre 代码块内无法转义，将 ``` 替换为全角字符避免提前结束代码块
+func (markdownFormatter) Pre(s string) string {
+	return "```\n" + strings.ReplaceAll(strings.Trim(s, "\n"), "```", "ˋˋˋ") + "\n```"
+}
+
+func (markdownFormatter) Link(url, label string) string {
+	return "[" + markdownEscaper.Replace(label) + "](" + strings.ReplaceAll(url, ")", "%29") + ")"
+}
+
+// slackEscaper Slack 只要求转义 &、<、>
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+type slackFormatter struct{}
+
+func (slackFormatter) ParseMode() string      { return "" }
+func (slackFormatter) Escape(s string) string { return slackEscaper.Replace(s) }
+func (slackFormatter) Bold(s string) string   { return "*" + slackEscaper.Replace(s) + "*" }
+
+func (slackFormatter) Pre(s string) string {
+	return "```\n" + slackEscaper.Replace(strings.Trim(s, "\n")) + "\n```"
+}
+
+// Link 链接文本中的 | 和 > 会截断链接，替换为相近字符
+func (slackFormatter) Link(url, label string) string {
+	label = strings.NewReplacer("|", "¦", ">", "›").Replace(label)
+	return "<" + slackEscaper.Replace(url) + "|" + slackEscaper.Replace(label) + ">"
+}
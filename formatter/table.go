@@ -2,188 +2,161 @@ package formatter
 
 import (
 	"fmt"
+	"hyper-notify-bot/config"
 	mongodb "hyper-notify-bot/db"
+	"hyper-notify-bot/i18n"
 	"math"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// FormatTableAsHTML 将表格数据格式化为HTML，settings 需已通过 Resolve 补全
+func FormatTableAsHTML(data []mongodb.PositionResult, coin, oraclePrice string, longSz, shortSz float64, settings config.CoinSettings) string {
+	return FormatTable(HTML, i18n.Default, data, coin, oraclePrice, longSz, shortSz, settings)
+}
+
+// FormatTableAsMarkdownV2 将表格数据格式化为 Telegram MarkdownV2
+func FormatTableAsMarkdownV2(data []mongodb.PositionResult, coin, oraclePrice string, longSz, shortSz float64, settings config.CoinSettings) string {
+	return FormatTable(MarkdownV2, i18n.Default, data, coin, oraclePrice, longSz, shortSz, settings)
+}
+
 // FormatTableAsText 将表格数据格式化为纯文本
-func FormatTableAsText(data []mongodb.TableRow) string {
-	// 创建表头
-	header := "编号      正          负"
-	separator := "-----------------------------"
+func FormatTableAsText(data []mongodb.PositionResult, coin, oraclePrice string, longSz, shortSz float64, settings config.CoinSettings) string {
+	return FormatTable(Text, i18n.Default, data, coin, oraclePrice, longSz, shortSz, settings)
+}
+
+// FormatTable 按 f 的格式和 locale 语言渲染 table 模板生成仓位分布表，settings 需已通过 Resolve 补全
+func FormatTable(f Formatter, locale string, data []mongodb.PositionResult, coin, oraclePrice string, longSz, shortSz float64, settings config.CoinSettings) string {
+	return FormatReport(f, locale, NewReportView(data, coin, oraclePrice, longSz, shortSz, settings))
+}
+
+// FormatReport 按 f 的格式和 locale 语言使用 view 渲染 table 模板
+func FormatReport(f Formatter, locale string, view ReportView) string {
+	return render(f, locale, "table", view)
+}
+
+// ReportView 模板数据
+type ReportView struct {
+	Coin         string
+	OraclePrice  string        // Oracle 价格原文，未获取到时为 N/A
+	LongSz       float64       // Long 总数
+	ShortSz      float64       // Short 总数（负数）
+	LongPercent  float64       // Long 占总仓位的比例（0-1）
+	ShortPercent float64       // Short 占总仓位的比例（0-1）
+	Precision    int           // 价格小数位数
+	BinSize      float64       // 分箱宽度
+	Rows         []ReportRow   // 以最接近 Oracle 价格的分箱为中心，最多 WindowRows 行
+	TradeURL     string        // Hyperliquid 交易页面，未获取到价格时为空
+	PriceAge     time.Duration // Oracle 价格已过期时为距上次更新的时长，否则为 0
+}
+
+// ReportRow 表格中的一个价格分箱
+type ReportRow struct {
+	Bin          float64
+	Long         float64
+	Short        float64 // 负数
+	LongPercent  float64 // 占 Long 总数的比例（0-1）
+	ShortPercent float64 // 占 Short 总数的比例（0-1）
+	Closest      bool    // 是否为最接近 Oracle 价格的分箱
+}
 
-	// 创建表格行
-	table := header + "\n" + separator + "\n"
-	for _, row := range data {
-		table += fmt.Sprintf("%-4.1f    %9.2f    %9.2f\n", row.ID, row.Pos, row.Neg)
+// NewReportView 生成模板数据，settings 需已通过 Resolve 补全
+func NewReportView(data []mongodb.PositionResult, coin, oraclePrice string, longSz, shortSz float64, settings config.CoinSettings) ReportView {
+	total := longSz + math.Abs(shortSz)
+	view := ReportView{
+		Coin:         coin,
+		OraclePrice:  oraclePrice,
+		LongSz:       longSz,
+		ShortSz:      shortSz,
+		LongPercent:  ratio(longSz, total),
+		ShortPercent: ratio(math.Abs(shortSz), total),
+		Precision:    settings.Precision,
+		BinSize:      settings.BinSize,
 	}
-	return table
+	if oraclePrice != "N/A" {
+		view.TradeURL = fmt.Sprintf("https://app.hyperliquid.xyz/trade/%s/USDC", url.PathEscape(strings.ToUpper(coin)))
+	}
+
+	showData, closestIndex := windowRows(data, closestBin(data, oraclePrice), settings.WindowRows)
+	for i, row := range showData {
+		var r ReportRow
+		r.Bin, _ = strconv.ParseFloat(row.Bin.String(), 64)
+		r.Long, _ = strconv.ParseFloat(row.Long.String(), 64)
+		r.Short, _ = strconv.ParseFloat(row.Short.String(), 64)
+		r.LongPercent = ratio(r.Long, longSz)
+		r.ShortPercent = ratio(math.Abs(r.Short), math.Abs(shortSz))
+		r.Closest = i == closestIndex
+		view.Rows = append(view.Rows, r)
+	}
+	return view
 }
 
-// FormatTableAsHTML 将表格数据格式化为HTML
-func FormatTableAsHTML(data []mongodb.PositionResult, coin, oraclePrice string, longSz, shortSz float64) string {
-	// 解析 oraclePrice 为浮点数以便比较
+// sampleView 加载模板时用于试渲染的示例数据
+var sampleView = ReportView{
+	Coin: "BTC", OraclePrice: "100000.5", LongSz: 1500, ShortSz: -500,
+	LongPercent: 0.75, ShortPercent: 0.25, Precision: 0, BinSize: 100,
+	Rows: []ReportRow{
+		{Bin: 99900, Long: 1000, Short: -100, LongPercent: 2.0 / 3, ShortPercent: 0.2},
+		{Bin: 100000, Long: 500, Short: -400, LongPercent: 1.0 / 3, ShortPercent: 0.8, Closest: true},
+	},
+	TradeURL: "https://app.hyperliquid.xyz/trade/BTC/USDC",
+	PriceAge: 7 * time.Minute,
+}
+
+// ratio 返回 part/total，total 为 0 时返回 0，避免没有仓位时出现 NaN
+func ratio(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part / total
+}
+
+// closestBin 返回最接近 Oracle 价格的分箱下标，价格无效（如 N/A）或没有可解析的分箱时返回 -1
+func closestBin(data []mongodb.PositionResult, oraclePrice string) int {
 	targetPrice, err := strconv.ParseFloat(oraclePrice, 64)
-	if err != nil {
-		// 处理解析错误，例如使用默认值或返回错误信息
-		targetPrice = 0
+	if err != nil || targetPrice <= 0 {
+		return -1
 	}
-	// 初始化最接近值的索引和最小差值
+
 	closestIndex := -1
 	minDiff := math.MaxFloat64
-
-	// 1. 遍历 data，找到最接近 oraclePrice 的 binF
 	for i, row := range data {
 		binF, err := strconv.ParseFloat(row.Bin.String(), 64)
 		if err != nil {
 			continue // 跳过无法解析的项
 		}
-		diff := math.Abs(binF - targetPrice)
-		if diff < minDiff {
+		if diff := math.Abs(binF - targetPrice); diff < minDiff {
 			minDiff = diff
 			closestIndex = i
 		}
 	}
+	return closestIndex
+}
 
-	percentLong := longSz / (math.Abs(shortSz) + longSz)
-	percentShort := 1 - percentLong
-
-	percentLongStr := fmt.Sprintf("%.2f%%", percentLong*100)
-	percentShortStr := fmt.Sprintf("%.2f%%", percentShort*100)
-	// 创建表头
-	table := `<b>📊 Position Data</b>`
-	// 添加 Oracle 价格
-	if oraclePrice != "" {
-		table += fmt.Sprintf("\n\n<b>当前 "+coin+" Oracle 价格: %s</b>", formatStringNumber(oraclePrice))
-		table += fmt.Sprintf("\n\n<b>统计 "+coin+" Long 总数: %9s</b>", formatStringNumber(fmt.Sprintf("%.2f", longSz)))
-	}
-	table += `
-<pre>
-💰Price   🟢Long(` + percentLongStr + `)
-------------------------------
-
-`
-	var showData []mongodb.PositionResult
-	if len(data) > 30 {
-		showData = data[closestIndex-10 : closestIndex+10]
-		closestIndex = 10
-	} else {
-		showData = data
+// windowRows 以 closestIndex 为中心截取最多 rows 行，返回截取后的数据及中心行的新索引；
+// closestIndex 为 -1 时截取中间部分，返回的索引仍为 -1
+func windowRows(data []mongodb.PositionResult, closestIndex, rows int) ([]mongodb.PositionResult, int) {
+	if rows <= 0 || len(data) <= rows {
+		return data, closestIndex
 	}
 
-	maxLong := 0.0
-	maxShort := 0.0
-	maxLongVauleIndex := 0
-	maxShortVauleIndex := 0
-	for i, row := range showData {
-		longTmp, _ := strconv.ParseFloat(row.Long.String(), 64)
-		shortTmp, _ := strconv.ParseFloat(row.Short.String(), 64)
-		//maxBinF, _ := strconv.ParseFloat(row.Bin.String(), 64)
-		if longTmp > maxLong {
-			maxLong = longTmp
-			maxLongVauleIndex = i
-		}
-		if shortTmp < maxShort {
-			maxShort = shortTmp
-			maxShortVauleIndex = i
-		}
+	center := closestIndex
+	if center < 0 {
+		center = len(data) / 2
 	}
-
-	percentMaxL := maxLong / longSz
-	percentMaxS := math.Abs(maxShort) / math.Abs(shortSz)
-
-	curMaxPercentLongStr := fmt.Sprintf("%.2f%%", percentMaxL*100)
-	curMaxPercentShortStr := fmt.Sprintf("%.2f%%", percentMaxS*100)
-
-	maxLongBinF, _ := strconv.ParseFloat(showData[maxLongVauleIndex].Bin.String(), 64)
-	maxShortBinF, _ := strconv.ParseFloat(showData[maxShortVauleIndex].Bin.String(), 64)
-
-	//tmpMaxL := fmt.Sprintf("%9.2f(%s)", maxLong, curMaxPercentLongStr)
-	//tmpMaxS := fmt.Sprintf("%9.2f(%s)", maxShort, curMaxPercentShortStr)
-
-	tmpMaxL := fmt.Sprintf("🔸%-4.2f  %9.2f(%s)", maxLongBinF, maxLong, curMaxPercentLongStr)
-	tmpMaxS := fmt.Sprintf("🔸%-4.2f  %9.2f(%s)", maxShortBinF, maxShort, curMaxPercentShortStr)
-
-	maxLengthL := len(tmpMaxL)
-	maxLengthS := len(tmpMaxS)
-
-	// 创建表格行
-	tableLong := ``
-	tableShort := ``
-	for i, row := range showData {
-		binF, _ := strconv.ParseFloat(row.Bin.String(), 64)
-		longF, _ := strconv.ParseFloat(row.Long.String(), 64)
-		shortF, _ := strconv.ParseFloat(row.Short.String(), 64)
-
-		percentL := longF / longSz
-		percentS := math.Abs(shortF) / math.Abs(shortSz)
-
-		curPercentLongStr := fmt.Sprintf("%.2f%%", percentL*100)
-		curPercentShortStr := fmt.Sprintf("%.2f%%", percentS*100)
-
-		//barsL := formatPercentWithBars(longF / maxLong)
-		//barsS := formatPercentWithBars(math.Abs(shortF) / math.Abs(maxShort))
-
-		n := "2"
-		if binF > 99999 {
-			n = "1"
-		}
-
-		curStrL := fmt.Sprintf("%9.2f(%s)", longF, curPercentLongStr)
-		curStrS := fmt.Sprintf("%9.2f(%s)", shortF, curPercentShortStr)
-		//为了列对齐，补充空格
-		spacesNumL := "  "
-		spacesNumS := "  "
-		for n := 0; n < (maxLengthL - len(curStrL)); n++ {
-			spacesNumL += " "
-		}
-		for n := 0; n < (maxLengthS - len(curStrS)); n++ {
-			spacesNumS += " "
-		}
-
-		// 2. 判断是否为最接近的行，如果是则加粗
-		if i == closestIndex {
-			tableLong += fmt.Sprintf("🔸%-4."+n+"f  %9s(%s)\n", binF, formatStringNumber(fmt.Sprintf("%.2f ", longF)), curPercentLongStr)
-			tableShort += fmt.Sprintf("🔸%-4."+n+"f  %9s(%s)\n", binF, formatStringNumber(fmt.Sprintf("%.2f ", shortF)), curPercentShortStr)
-		} else {
-			tableLong += fmt.Sprintf("🔹%-4."+n+"f  %9s(%s)\n", binF, formatStringNumber(fmt.Sprintf("%.2f ", longF)), curPercentLongStr)
-			tableShort += fmt.Sprintf("🔹%-4."+n+"f  %9s(%s)\n", binF, formatStringNumber(fmt.Sprintf("%.2f ", shortF)), curPercentShortStr)
-		}
-
-		////为了列对齐，补充空格
-		//spacesBeginNumL := ""
-		//spacesBeginNumS := ""
-		//for n := 0; n < (maxLengthL - len(barsL) - 3); n++ {
-		//	spacesBeginNumL += " "
-		//}
-		//for n := 0; n < (maxLengthS - len(barsS) - 3); n++ {
-		//	spacesBeginNumS += " "
-		//}
-		//
-		//tableLong += fmt.Sprintf("%s%s\n", spacesBeginNumL, barsL)
-		//tableLong += "------------------------------\n"
-		//
-		//tableShort += fmt.Sprintf("%s%s\n", spacesBeginNumS, barsS)
-		//tableShort += "------------------------------\n"
-	}
-
-	table += tableLong + "</pre>\n\n"
-	table += fmt.Sprintf("<b>统计 "+coin+" Short 总数: %9s</b>", formatStringNumber(fmt.Sprintf("%.2f ", shortSz)))
-	table += `
-<pre>
-💰Price     🔴Short(` + percentShortStr + `)
-------------------------------
-`
-	table += tableShort + "</pre>"
-	// 创建交易页面链接
-	if oraclePrice != "N/A" {
-		tradeURL := fmt.Sprintf("https://app.hyperliquid.xyz/trade/%s/USDC", strings.ToUpper(coin))
-		table += fmt.Sprintf("\n\n<a href=\"%s\">📈 查看更多 %s 交易数据</a>", tradeURL, strings.ToUpper(coin))
+	start := center - rows/2
+	if start < 0 {
+		start = 0
 	}
-
-	return table
+	if start+rows > len(data) {
+		start = len(data) - rows
+	}
+	if closestIndex < 0 {
+		return data[start : start+rows], -1
+	}
+	return data[start : start+rows], closestIndex - start
 }
 
 func formatPercentWithBars(percent float64) string {
@@ -205,7 +178,6 @@ func formatPercentWithBars(percent float64) string {
 
 	// 构建竖线字符串
 	bars := strings.Repeat("|", numBars)
-	fmt.Sprintf("%s (%.1f%%)", bars, percent*100)
 	// 为了直观，也返回原始的百分比数值
 	//return fmt.Sprintf("%s (%.1f%%)", bars, percent*100)
 	return bars
@@ -233,7 +205,6 @@ func formatStringNumber(s string) string {
 		integerPart = "0"
 	}
 
-	fmt.Println("------------------", integerPart, len(integerPart))
 	if len(integerPart) <= 3 {
 		return sign + integerPart + decimalPart
 	}
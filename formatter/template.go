@@ -0,0 +1,195 @@
+package formatter
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"hyper-notify-bot/i18n"
+)
+
+// builtinFS 内置模板，即默认的消息布局
+//
+//go:embed templates/*.tmpl
+var builtinFS embed.FS
+
+// requiredTemplates 必须定义的模板
+var requiredTemplates = []string{"table", "caption"}
+
+// Templates 消息模板集合
+type Templates struct {
+	tmpl *template.Template
+	Dir  string // 模板目录，为空表示仅使用内置模板
+}
+
+// LoadTemplates 加载内置模板，dir 不为空时用其中的 *.tmpl 覆盖同名模板。
+// 加载后使用示例数据按所有格式试渲染，尽早发现模板中的错误
+func LoadTemplates(dir string) (*Templates, error) {
+	tmpl, err := template.New("").Funcs(templateFuncs(Text, i18n.Default, nil)).ParseFS(builtinFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("解析内置模板失败: %v", err)
+	}
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			return nil, fmt.Errorf("读取模板目录 %s 失败: %v", dir, err)
+		}
+		for _, file := range files {
+			raw, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("读取模板 %s 失败: %v", file, err)
+			}
+			if _, err := tmpl.New(filepath.Base(file)).Parse(string(raw)); err != nil {
+				return nil, fmt.Errorf("解析模板 %s 失败: %v", file, err)
+			}
+		}
+	}
+
+	t := &Templates{tmpl: tmpl, Dir: dir}
+	for _, name := range requiredTemplates {
+		if tmpl.Lookup(name) == nil {
+			return nil, fmt.Errorf("缺少模板 %q", name)
+		}
+		for _, f := range []Formatter{HTML, MarkdownV2, Text, Markdown, Slack} {
+			for _, locale := range i18n.Languages() {
+				if _, err := t.Render(f, locale, name, sampleView); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+// Render 按 f 的格式和 locale 语言渲染模板
+func (t *Templates) Render(f Formatter, locale, name string, data interface{}) (string, error) {
+	tmpl, err := t.tmpl.Clone()
+	if err != nil {
+		return "", fmt.Errorf("渲染模板 %s 失败: %v", name, err)
+	}
+	tmpl.Funcs(templateFuncs(f, locale, tmpl))
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("渲染模板 %s 失败: %v", name, err)
+	}
+	return buf.String(), nil
+}
+
+// templateFuncs 模板函数，bold、escape、pre、link 按 f 的格式输出并转义，t 返回 locale 语言的文案
+func templateFuncs(f Formatter, locale string, tmpl *template.Template) template.FuncMap {
+	return template.FuncMap{
+		"t": func(key string, args ...interface{}) string {
+			return i18n.T(locale, key, args...)
+		},
+		"bold":   f.Bold,
+		"escape": f.Escape,
+		"pre":    f.Pre,
+		"link":   f.Link,
+		// include 将子模板渲染为字符串，用于传给 pre 等函数
+		"include": func(name string, data interface{}) (string, error) {
+			if tmpl == nil {
+				return "", nil
+			}
+			var buf bytes.Buffer
+			err := tmpl.ExecuteTemplate(&buf, name, data)
+			return buf.String(), err
+		},
+		"formatNumber": formatNumber,
+		"percent":      func(ratio float64) string { return fmt.Sprintf("%.2f%%", ratio*100) },
+		"fixed":        func(v float64, decimals int) string { return fmt.Sprintf("%.*f", decimals, v) },
+		"highlight":    highlight,
+		"age":          formatAge,
+		"upper":        strings.ToUpper,
+	}
+}
+
+// formatNumber 添加千分位逗号；v 为字符串时保留原有小数位，为数字时保留 decimals 位小数（默认 2 位）
+func formatNumber(v interface{}, decimals ...int) (string, error) {
+	switch n := v.(type) {
+	case string:
+		return formatStringNumber(n), nil
+	case float64:
+		d := 2
+		if len(decimals) > 0 {
+			d = decimals[0]
+		}
+		return formatStringNumber(fmt.Sprintf("%.*f", d, n)), nil
+	case int:
+		return formatStringNumber(fmt.Sprint(n)), nil
+	default:
+		return "", fmt.Errorf("formatNumber 不支持的类型 %T", v)
+	}
+}
+
+// formatAge 将时长格式化为 45s、7m、2h5m 的简短形式
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d%time.Hour < time.Minute:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int((d % time.Hour).Minutes()))
+	}
+}
+
+// highlight 最接近 Oracle 价格的行返回 🔸，其余行返回 🔹
+func highlight(row ReportRow) string {
+	if row.Closest {
+		return "🔸"
+	}
+	return "🔹"
+}
+
+var (
+	templatesMu sync.RWMutex
+	// builtinTemplates 内置模板，自定义模板渲染失败时使用
+	builtinTemplates = mustLoadBuiltinTemplates()
+	currentTemplates = builtinTemplates
+)
+
+func mustLoadBuiltinTemplates() *Templates {
+	t, err := LoadTemplates("")
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// SetTemplates 替换消息模板，用于启动和热更新
+func SetTemplates(t *Templates) {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+	currentTemplates = t
+}
+
+// render 使用当前模板渲染，自定义模板出错时记录日志并回退到内置模板
+func render(f Formatter, locale, name string, data interface{}) string {
+	templatesMu.RLock()
+	t := currentTemplates
+	templatesMu.RUnlock()
+
+	out, err := t.Render(f, locale, name, data)
+	if err == nil {
+		return out
+	}
+	if t != builtinTemplates {
+		log.Printf("%v，使用内置模板", err)
+		if out, err = builtinTemplates.Render(f, locale, name, data); err == nil {
+			return out
+		}
+	}
+	log.Printf("%v", err)
+	return f.Escape(err.Error())
+}
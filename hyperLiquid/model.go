@@ -1,6 +1,11 @@
 package hyperliquid
 
-import "time"
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // WebSocketRequest 订阅请求
 type SubscribeRequest struct {
@@ -8,12 +13,78 @@ type SubscribeRequest struct {
 	Subscription Subscription `json:"subscription"`
 }
 
+// PingRequest 心跳请求，服务端回复 channel 为 pong 的消息
+type PingRequest struct {
+	Method string `json:"method"`
+}
+
+// SubscriptionActiveAssetCtx 资产上下文订阅，推送 Oracle 价格等数据
+const SubscriptionActiveAssetCtx = "activeAssetCtx"
+
+// Subscription 订阅内容，Type 为 Hyperliquid 的订阅类型（如 activeAssetCtx、trades、l2Book、candle、userFills），
+// 其余字段按类型填写，未使用的字段留空
 type Subscription struct {
-	Type string `json:"type"`
-	Coin string `json:"coin"`
+	Type            string `json:"type"`
+	Coin            string `json:"coin,omitempty"`
+	User            string `json:"user,omitempty"`
+	Interval        string `json:"interval,omitempty"` // candle 的 K 线周期，如 1m
+	NSigFigs        int    `json:"nSigFigs,omitempty"` // l2Book 的有效数字位数
+	Mantissa        int    `json:"mantissa,omitempty"`
+	AggregateByTime bool   `json:"aggregateByTime,omitempty"` // userFills 是否合并同一时间的成交
+}
+
+// ActiveAssetCtx 返回币种的资产上下文订阅
+func ActiveAssetCtx(coin string) Subscription {
+	return Subscription{Type: SubscriptionActiveAssetCtx, Coin: coin}
+}
+
+// String 返回用于日志的订阅描述，包含所有非空字段，如 activeAssetCtx:BTC、candle:ETH:1m、l2Book:BTC:nSigFigs=5
+func (s Subscription) String() string {
+	parts := []string{s.Type}
+	for _, field := range []string{s.Coin, s.User, s.Interval} {
+		if field != "" {
+			parts = append(parts, field)
+		}
+	}
+	if s.NSigFigs != 0 {
+		parts = append(parts, "nSigFigs="+strconv.Itoa(s.NSigFigs))
+	}
+	if s.Mantissa != 0 {
+		parts = append(parts, "mantissa="+strconv.Itoa(s.Mantissa))
+	}
+	if s.AggregateByTime {
+		parts = append(parts, "aggregateByTime")
+	}
+	return strings.Join(parts, ":")
+}
+
+// less 按全部字段比较订阅，用于得到确定的排序
+func (s Subscription) less(o Subscription) bool {
+	switch {
+	case s.Type != o.Type:
+		return s.Type < o.Type
+	case s.Coin != o.Coin:
+		return s.Coin < o.Coin
+	case s.User != o.User:
+		return s.User < o.User
+	case s.Interval != o.Interval:
+		return s.Interval < o.Interval
+	case s.NSigFigs != o.NSigFigs:
+		return s.NSigFigs < o.NSigFigs
+	case s.Mantissa != o.Mantissa:
+		return s.Mantissa < o.Mantissa
+	default:
+		return !s.AggregateByTime && o.AggregateByTime
+	}
+}
+
+// WebSocketMessage 服务端推送的消息，Data 按 Channel 解析
+type WebSocketMessage struct {
+	Channel string          `json:"channel"`
+	Data    json.RawMessage `json:"data"`
 }
 
-// WebSocketResponse WebSocket 响应
+// WebSocketResponse activeAssetCtx 频道的消息
 type WebSocketResponse struct {
 	Channel string `json:"channel"`
 	Data    struct {
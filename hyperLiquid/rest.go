@@ -0,0 +1,119 @@
+package hyperliquid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// InfoURL Hyperliquid 信息查询接口
+const InfoURL = "https://api.hyperliquid.xyz/info"
+
+// metaAndAssetCtxs 响应为 [meta, assetCtxs]，assetCtxs 与 meta.universe 按下标一一对应
+type assetMeta struct {
+	Universe []struct {
+		Name string `json:"name"`
+	} `json:"universe"`
+}
+
+type assetCtx struct {
+	OraclePx string `json:"oraclePx"`
+}
+
+// FetchOraclePrices 通过 REST 接口查询所有永续合约的 Oracle 价格快照，WebSocket 价格不可用时作为回退
+func FetchOraclePrices(ctx context.Context) (map[string]OraclePrice, error) {
+	raw, err := postInfo(ctx, "metaAndAssetCtxs")
+	if err != nil {
+		return nil, fmt.Errorf("查询 Oracle 价格失败: %v", err)
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal(raw, &parts); err != nil || len(parts) != 2 {
+		return nil, fmt.Errorf("解析 Oracle 价格失败: 响应格式无效")
+	}
+	var meta assetMeta
+	if err := json.Unmarshal(parts[0], &meta); err != nil {
+		return nil, fmt.Errorf("解析 Oracle 价格失败: %v", err)
+	}
+	var ctxs []assetCtx
+	if err := json.Unmarshal(parts[1], &ctxs); err != nil {
+		return nil, fmt.Errorf("解析 Oracle 价格失败: %v", err)
+	}
+
+	now := time.Now()
+	prices := make(map[string]OraclePrice, len(ctxs))
+	for i, asset := range meta.Universe {
+		if i >= len(ctxs) {
+			break
+		}
+		if ctxs[i].OraclePx == "" {
+			continue
+		}
+		prices[asset.Name] = OraclePrice{Coin: asset.Name, OraclePx: ctxs[i].OraclePx, Timestamp: now}
+	}
+	return prices, nil
+}
+
+// UnknownCoins 通过 meta 接口查询永续合约列表，返回 coins 中 Hyperliquid 未上线的币种。
+// 现货（@107、PURR/USDC）和 HIP-3（dex:COIN）币种不在永续合约列表中，不做检查
+func UnknownCoins(ctx context.Context, coins []string) ([]string, error) {
+	raw, err := postInfo(ctx, "meta")
+	if err != nil {
+		return nil, fmt.Errorf("查询币种列表失败: %v", err)
+	}
+	var meta assetMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return nil, fmt.Errorf("解析币种列表失败: %v", err)
+	}
+	if len(meta.Universe) == 0 {
+		return nil, fmt.Errorf("解析币种列表失败: 币种列表为空")
+	}
+
+	listed := make(map[string]bool, len(meta.Universe))
+	for _, asset := range meta.Universe {
+		listed[asset.Name] = true
+	}
+	var unknown []string
+	for _, coin := range coins {
+		if strings.ContainsAny(coin, "@/:") {
+			continue
+		}
+		if !listed[coin] {
+			unknown = append(unknown, coin)
+		}
+	}
+	return unknown, nil
+}
+
+// postInfo 向信息查询接口发送 {"type": infoType} 请求并返回响应内容
+func postInfo(ctx context.Context, infoType string) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"type": infoType})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, InfoURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(raw))
+	}
+	return raw, nil
+}
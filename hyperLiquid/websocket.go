@@ -3,7 +3,11 @@ package hyperliquid
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,115 +15,451 @@ import (
 )
 
 const (
-	WebSocketURL   = "wss://api.hyperliquid.xyz/ws"
-	ReconnectDelay = 5 * time.Second
+	WebSocketURL = "wss://api.hyperliquid.xyz/ws"
+	// MinReconnectDelay 首次重连前的等待时间，之后每次失败翻倍
+	MinReconnectDelay = time.Second
+	// MaxReconnectDelay 重连等待时间上限
+	MaxReconnectDelay = 2 * time.Minute
+	// DefaultPingInterval 默认 ping 间隔，Hyperliquid 会关闭 60 秒内没有收到消息的连接
+	DefaultPingInterval = 30 * time.Second
+	// DefaultIdleTimeout 默认空闲超时，超过该时间未收到任何消息（包括 pong）时判定连接失效
+	DefaultIdleTimeout = 60 * time.Second
+	// writeTimeout 单条消息的写超时
+	writeTimeout = 10 * time.Second
 )
 
+// ErrClosed 客户端已关闭
+var ErrClosed = errors.New("WebSocket 客户端已关闭")
+
+// ConnState WebSocket 连接状态
+type ConnState int
+
+const (
+	StateDisconnected ConnState = iota
+	StateConnecting
+	StateConnected
+	StateClosed
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// StateEvent 连接状态变化事件
+type StateEvent struct {
+	State   ConnState
+	Err     error         // 导致断开或连接失败的错误
+	Attempt int           // 连续失败的重连次数
+	RetryIn time.Duration // 断开时距下次重连的等待时间
+	Time    time.Time
+}
+
+// WebSocketClient 维护到 Hyperliquid 的 WebSocket 连接并缓存 Oracle 价格。
+//
+// 连接由 StartListening 启动的两个 goroutine 管理：读 goroutine 负责建立连接、读取消息和断线重连，
+// 写 goroutine（writeLoop）是唯一向连接写入的地方，负责发送订阅变化和 ping。
+// Subscribe、Unsubscribe 可以在任意时刻、任意 goroutine 调用：只修改订阅集合并通知 writeLoop，
+// 未连接时变化保留在订阅集合中，连接建立后一并发送
 type WebSocketClient struct {
-	conn         *websocket.Conn
-	mu           sync.RWMutex
-	oraclePrices map[string]OraclePrice // coin -> OraclePrice
-	ctx          context.Context
-	cancel       context.CancelFunc
+	URL string // 默认为 WebSocketURL
+	// OnMessage 接收 activeAssetCtx 以外频道的消息，需在 StartListening 之前设置，在读 goroutine 中调用
+	OnMessage func(channel string, data json.RawMessage)
+
+	mu            sync.RWMutex
+	conn          *websocket.Conn           // 当前连接，用于 Close 时中断读取；只由 writeLoop 写入消息
+	oraclePrices  map[string]OraclePrice    // coin -> OraclePrice
+	subscriptions map[Subscription]struct{} // 订阅集合，重连后全部重新订阅
+	state         ConnState
+	pingInterval  time.Duration
+	idleTimeout   time.Duration
+
+	events  chan StateEvent
+	changed chan struct{}        // 订阅集合变化时通知 writeLoop，多次变化合并为一次
+	conns   chan *websocket.Conn // 连接建立（非 nil）或断开（nil）时交给 writeLoop
+	ctx     context.Context
+	cancel  context.CancelFunc
 }
 
 func NewWebSocketClient() *WebSocketClient {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &WebSocketClient{
-		oraclePrices: make(map[string]OraclePrice),
-		ctx:          ctx,
-		cancel:       cancel,
+		URL:           WebSocketURL,
+		oraclePrices:  make(map[string]OraclePrice),
+		subscriptions: make(map[Subscription]struct{}),
+		pingInterval:  DefaultPingInterval,
+		idleTimeout:   DefaultIdleTimeout,
+		events:        make(chan StateEvent, 16),
+		changed:       make(chan struct{}, 1),
+		conns:         make(chan *websocket.Conn),
+		ctx:           ctx,
+		cancel:        cancel,
 	}
 }
 
-func (c *WebSocketClient) Connect() error {
-	dialer := websocket.DefaultDialer
-	conn, _, err := dialer.Dial(WebSocketURL, nil)
-	if err != nil {
-		return err
+// SetHeartbeat 设置 ping 间隔和空闲超时，从下次发送 ping 或读取消息开始生效
+func (c *WebSocketClient) SetHeartbeat(pingInterval, idleTimeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pingInterval = pingInterval
+	c.idleTimeout = idleTimeout
+}
+
+// heartbeat 返回当前的 ping 间隔和空闲超时
+func (c *WebSocketClient) heartbeat() (time.Duration, time.Duration) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pingInterval, c.idleTimeout
+}
+
+// StateChanges 返回连接状态变化事件，未及时读取导致通道已满时丢弃新事件
+func (c *WebSocketClient) StateChanges() <-chan StateEvent {
+	return c.events
+}
+
+// State 返回当前连接状态
+func (c *WebSocketClient) State() ConnState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
+}
+
+// setState 更新连接状态并发送事件
+func (c *WebSocketClient) setState(event StateEvent) {
+	c.mu.Lock()
+	if c.state == StateClosed {
+		c.mu.Unlock()
+		return
 	}
-	c.conn = conn
+	c.state = event.State
+	c.mu.Unlock()
+
+	event.Time = time.Now()
+	select {
+	case c.events <- event:
+	default:
+	}
+}
+
+// Subscribe 添加任意类型的订阅，未连接时在连接建立后发送；重复订阅不会重复发送
+func (c *WebSocketClient) Subscribe(sub Subscription) error {
+	if sub.Type == "" {
+		return errors.New("订阅类型不能为空")
+	}
+	if c.ctx.Err() != nil {
+		return ErrClosed
+	}
+
+	c.mu.Lock()
+	c.subscriptions[sub] = struct{}{}
+	c.mu.Unlock()
+
+	c.notify()
 	return nil
 }
 
-func (c *WebSocketClient) Subscribe(coin string) error {
-	request := SubscribeRequest{
-		Method: "subscribe",
-		Subscription: Subscription{
-			Type: "activeAssetCtx",
-			Coin: coin,
-		},
+// SubscribeCoin 订阅币种的 Oracle 价格
+func (c *WebSocketClient) SubscribeCoin(coin string) error {
+	return c.Subscribe(ActiveAssetCtx(coin))
+}
+
+// UnsubscribeFrom 取消任意类型的订阅，取消 activeAssetCtx 时同时清除缓存的 Oracle 价格
+func (c *WebSocketClient) UnsubscribeFrom(sub Subscription) error {
+	if c.ctx.Err() != nil {
+		return ErrClosed
 	}
 
-	message, err := json.Marshal(request)
-	if err != nil {
-		return err
+	c.mu.Lock()
+	delete(c.subscriptions, sub)
+	if sub.Type == SubscriptionActiveAssetCtx {
+		delete(c.oraclePrices, sub.Coin)
 	}
+	c.mu.Unlock()
+
+	c.notify()
+	return nil
+}
 
-	return c.conn.WriteMessage(websocket.TextMessage, message)
+// Unsubscribe 取消订阅币种并清除缓存的 Oracle 价格
+func (c *WebSocketClient) Unsubscribe(coin string) error {
+	return c.UnsubscribeFrom(ActiveAssetCtx(coin))
 }
 
+// ListSubscriptions 返回当前的订阅集合（包括未连接时尚未发送的订阅），依次按类型、币种、用户等全部字段排序
+func (c *WebSocketClient) ListSubscriptions() []Subscription {
+	c.mu.RLock()
+	subs := make([]Subscription, 0, len(c.subscriptions))
+	for sub := range c.subscriptions {
+		subs = append(subs, sub)
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(subs, func(i, j int) bool { return subs[i].less(subs[j]) })
+	return subs
+}
+
+// notify 通知 writeLoop 订阅集合已变化，已有未处理的通知时直接返回
+func (c *WebSocketClient) notify() {
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+// handoff 将新连接（或断开时的 nil）交给 writeLoop，客户端关闭时返回 false
+func (c *WebSocketClient) handoff(conn *websocket.Conn) bool {
+	select {
+	case c.conns <- conn:
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
+}
+
+// reconnectDelay 第 attempt 次重连前的等待时间：指数退避并加入随机抖动，避免所有实例同时重连
+func reconnectDelay(attempt int) time.Duration {
+	delay := MaxReconnectDelay
+	if attempt < 16 {
+		if d := MinReconnectDelay << attempt; d < MaxReconnectDelay {
+			delay = d
+		}
+	}
+	// 在 [delay/2, delay) 之间随机
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
+
+// wait 等待 d，客户端关闭时返回 false
+func (c *WebSocketClient) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-c.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// StartListening 在后台维持连接：连接断开或失败时按指数退避重连，重连后重新订阅所有币种
 func (c *WebSocketClient) StartListening() {
+	go c.writeLoop()
 	go func() {
+		attempt := 0
 		for {
-			select {
-			case <-c.ctx.Done():
+			received, err := c.run()
+			if c.ctx.Err() != nil {
+				return
+			}
+			// 连接曾正常收到消息时重置退避
+			if received {
+				attempt = 0
+			}
+			delay := reconnectDelay(attempt)
+			attempt++
+			c.setState(StateEvent{State: StateDisconnected, Err: err, Attempt: attempt, RetryIn: delay})
+			if !c.wait(delay) {
 				return
-			default:
-				if c.conn == nil {
-					if err := c.Connect(); err != nil {
-						log.Printf("连接失败，将在 %v 后重试: %v", ReconnectDelay, err)
-						time.Sleep(ReconnectDelay)
-						continue
-					}
-
-					// 重新订阅所有币种
-					c.mu.RLock()
-					coins := make([]string, 0, len(c.oraclePrices))
-					for coin := range c.oraclePrices {
-						coins = append(coins, coin)
-					}
-					c.mu.RUnlock()
-
-					for _, coin := range coins {
-						if err := c.Subscribe(coin); err != nil {
-							log.Printf("重新订阅 %s 失败: %v", coin, err)
-						}
-					}
-				}
-
-				_, message, err := c.conn.ReadMessage()
-				if err != nil {
-					log.Printf("读取消息错误: %v", err)
-					c.conn.Close()
-					c.conn = nil
-					continue
-				}
-
-				var response WebSocketResponse
-				if err := json.Unmarshal(message, &response); err != nil {
-					log.Printf("解析消息错误: %v", err)
-					continue
-				}
-
-				if response.Channel == "activeAssetCtx" {
-					oraclePrice := OraclePrice{
-						Coin:      response.Data.Coin,
-						OraclePx:  response.Data.Ctx.OraclePx,
-						Timestamp: time.Now(),
-					}
-
-					c.mu.Lock()
-					c.oraclePrices[response.Data.Coin] = oraclePrice
-					c.mu.Unlock()
-
-					log.Printf("更新 %s 的 Oracle 价格: %s", response.Data.Coin, oraclePrice.OraclePx)
-				}
 			}
 		}
 	}()
 }
 
+// run 建立连接并交给 writeLoop 发送所有订阅（包括尚未收到过价格的币种），然后读取消息直到连接出错
+func (c *WebSocketClient) run() (received bool, err error) {
+	c.setState(StateEvent{State: StateConnecting})
+	conn, _, err := websocket.DefaultDialer.DialContext(c.ctx, c.URL, nil)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+		conn.Close()
+		c.handoff(nil)
+	}()
+	// Close 可能在保存连接之前执行，此时由这里关闭连接
+	if c.ctx.Err() != nil {
+		return false, ErrClosed
+	}
+
+	c.setState(StateEvent{State: StateConnected})
+	if !c.handoff(conn) {
+		return false, ErrClosed
+	}
+
+	// 协议层的 pong 同样说明连接存活
+	conn.SetPongHandler(func(string) error {
+		_, idle := c.heartbeat()
+		return conn.SetReadDeadline(time.Now().Add(idle))
+	})
+
+	for {
+		// 每次读取前刷新读超时，空闲超时内没有任何消息时 ReadMessage 返回超时错误
+		_, idle := c.heartbeat()
+		conn.SetReadDeadline(time.Now().Add(idle))
+
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			var netErr interface{ Timeout() bool }
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				err = fmt.Errorf("%v 内未收到任何消息，连接可能已失效: %w", idle, err)
+			}
+			return received, err
+		}
+		received = true
+		c.handleMessage(message)
+	}
+}
+
+// writeLoop 唯一的写 goroutine：连接建立后发送订阅集合，之后同步订阅变化并定期发送应用层 ping。
+// 写入失败时关闭连接，使读 goroutine 立即返回错误并重连
+func (c *WebSocketClient) writeLoop() {
+	var (
+		conn   *websocket.Conn
+		active map[Subscription]bool // 当前连接上已发送的订阅
+		ping   *time.Timer
+		pingC  <-chan time.Time
+	)
+	resetPing := func() {
+		if ping != nil {
+			ping.Stop()
+		}
+		interval, _ := c.heartbeat()
+		ping = time.NewTimer(interval)
+		pingC = ping.C
+	}
+	defer func() {
+		if ping != nil {
+			ping.Stop()
+		}
+	}()
+
+	for {
+		var err error
+		select {
+		case <-c.ctx.Done():
+			return
+		case conn = <-c.conns:
+			if conn == nil {
+				pingC = nil
+				continue
+			}
+			active = make(map[Subscription]bool)
+			resetPing()
+			err = c.sync(conn, active)
+		case <-c.changed:
+			if conn == nil {
+				// 未连接，连接建立后会按订阅集合发送
+				continue
+			}
+			err = c.sync(conn, active)
+		case <-pingC:
+			err = c.write(conn, PingRequest{Method: "ping"})
+			resetPing()
+		}
+
+		if err != nil {
+			log.Printf("WebSocket 发送失败，断开重连: %v", err)
+			conn.Close()
+			conn, pingC = nil, nil
+		}
+	}
+}
+
+// sync 对比订阅集合与当前连接上已发送的订阅，发送差异部分
+func (c *WebSocketClient) sync(conn *websocket.Conn, active map[Subscription]bool) error {
+	subs := c.ListSubscriptions()
+	want := make(map[Subscription]bool, len(subs))
+	for _, sub := range subs {
+		want[sub] = true
+	}
+
+	for sub := range active {
+		if want[sub] {
+			continue
+		}
+		if err := c.write(conn, SubscribeRequest{Method: "unsubscribe", Subscription: sub}); err != nil {
+			return err
+		}
+		delete(active, sub)
+	}
+	for _, sub := range subs {
+		if active[sub] {
+			continue
+		}
+		if err := c.write(conn, SubscribeRequest{Method: "subscribe", Subscription: sub}); err != nil {
+			return err
+		}
+		active[sub] = true
+	}
+	return nil
+}
+
+// write 带写超时地发送一条 JSON 消息，只能在 writeLoop 中调用
+func (c *WebSocketClient) write(conn *websocket.Conn, request interface{}) error {
+	message, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return conn.WriteMessage(websocket.TextMessage, message)
+}
+
+// handleMessage 处理收到的消息：activeAssetCtx 更新 Oracle 价格缓存，其余频道交给 OnMessage
+func (c *WebSocketClient) handleMessage(message []byte) {
+	var envelope WebSocketMessage
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		log.Printf("解析消息错误: %v", err)
+		return
+	}
+
+	switch envelope.Channel {
+	case "pong", "subscriptionResponse":
+		// ping 和订阅请求的响应
+	case "error":
+		log.Printf("WebSocket 请求错误: %s", envelope.Data)
+	case SubscriptionActiveAssetCtx:
+		var response WebSocketResponse
+		if err := json.Unmarshal(message, &response); err != nil {
+			log.Printf("解析消息错误: %v", err)
+			return
+		}
+		oraclePrice := OraclePrice{
+			Coin:      response.Data.Coin,
+			OraclePx:  response.Data.Ctx.OraclePx,
+			Timestamp: time.Now(),
+		}
+
+		// 已取消订阅的币种可能仍有消息在途，不再缓存
+		c.mu.Lock()
+		if _, ok := c.subscriptions[ActiveAssetCtx(response.Data.Coin)]; ok {
+			c.oraclePrices[response.Data.Coin] = oraclePrice
+		}
+		c.mu.Unlock()
+
+		log.Printf("更新 %s 的 Oracle 价格: %s", response.Data.Coin, oraclePrice.OraclePx)
+	default:
+		if c.OnMessage != nil {
+			c.OnMessage(envelope.Channel, envelope.Data)
+		}
+	}
+}
+
 func (c *WebSocketClient) GetOraclePrice(coin string) (OraclePrice, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -128,9 +468,15 @@ func (c *WebSocketClient) GetOraclePrice(coin string) (OraclePrice, bool) {
 	return price, exists
 }
 
+// Close 关闭客户端，中断当前连接并停止重连
 func (c *WebSocketClient) Close() {
+	c.setState(StateEvent{State: StateClosed})
 	c.cancel()
-	if c.conn != nil {
-		c.conn.Close()
+
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	if conn != nil {
+		conn.Close()
 	}
 }
@@ -0,0 +1,574 @@
+package hyperliquid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// frame 服务端收到的一条请求
+type frame struct {
+	conn         int          // 收到请求的连接序号，从 0 开始
+	Method       string       `json:"method"`
+	Subscription Subscription `json:"subscription"`
+}
+
+func (f frame) String() string {
+	if f.Method == "ping" {
+		return "ping"
+	}
+	return f.Method + " " + f.Subscription.String()
+}
+
+// wsServer 模拟 Hyperliquid WebSocket 服务端，记录每个连接收到的请求和当前订阅
+type wsServer struct {
+	t    *testing.T
+	srv  *httptest.Server
+	pong bool // 是否回复 ping
+
+	frames    chan frame
+	connected chan int
+
+	mu    sync.Mutex
+	conns []*websocket.Conn
+	subs  []map[Subscription]bool // 每个连接上当前生效的订阅
+}
+
+func newWSServer(t *testing.T, pong bool) *wsServer {
+	t.Helper()
+	s := &wsServer{
+		t:         t,
+		pong:      pong,
+		frames:    make(chan frame, 1024),
+		connected: make(chan int, 64),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(func() {
+		s.dropAll()
+		s.srv.Close()
+	})
+	return s
+}
+
+func (s *wsServer) handle(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.t.Errorf("升级 WebSocket 失败: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	s.mu.Lock()
+	idx := len(s.conns)
+	s.conns = append(s.conns, conn)
+	s.subs = append(s.subs, make(map[Subscription]bool))
+	s.mu.Unlock()
+	select {
+	case s.connected <- idx:
+	default:
+	}
+
+	// 先推送一条消息，客户端据此认为连接正常，断开后按最短的退避时间重连
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"subscriptionResponse","data":{}}`))
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		f := frame{conn: idx}
+		if err := json.Unmarshal(message, &f); err != nil {
+			s.t.Errorf("解析请求失败: %v: %s", err, message)
+			return
+		}
+
+		s.mu.Lock()
+		switch f.Method {
+		case "subscribe":
+			s.subs[idx][f.Subscription] = true
+		case "unsubscribe":
+			delete(s.subs[idx], f.Subscription)
+		}
+		s.mu.Unlock()
+		select {
+		case s.frames <- f:
+		default:
+		}
+
+		if f.Method == "ping" && s.pong {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"pong"}`))
+		}
+	}
+}
+
+// url 返回客户端使用的 ws:// 地址
+func (s *wsServer) url() string {
+	return "ws" + strings.TrimPrefix(s.srv.URL, "http")
+}
+
+// drop 从服务端断开第 idx 个连接
+func (s *wsServer) drop(idx int) {
+	s.mu.Lock()
+	conn := s.conns[idx]
+	s.mu.Unlock()
+	conn.Close()
+}
+
+func (s *wsServer) dropAll() {
+	s.mu.Lock()
+	conns := append([]*websocket.Conn(nil), s.conns...)
+	s.mu.Unlock()
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// connCount 返回已建立的连接数
+func (s *wsServer) connCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// active 返回第 idx 个连接上当前生效的订阅
+func (s *wsServer) active(idx int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var subs []string
+	for sub := range s.subs[idx] {
+		subs = append(subs, sub.String())
+	}
+	sort.Strings(subs)
+	return subs
+}
+
+// waitConn 等待新连接建立，返回连接序号
+func (s *wsServer) waitConn(t *testing.T, timeout time.Duration) int {
+	t.Helper()
+	select {
+	case idx := <-s.connected:
+		return idx
+	case <-time.After(timeout):
+		t.Fatalf("%v 内没有建立新连接", timeout)
+		return -1
+	}
+}
+
+// expectFrames 等待第 idx 个连接收到 n 条订阅请求（忽略 ping 和其他连接的请求）
+func (s *wsServer) expectFrames(t *testing.T, idx, n int, timeout time.Duration) []string {
+	t.Helper()
+	var got []string
+	deadline := time.After(timeout)
+	for len(got) < n {
+		select {
+		case f := <-s.frames:
+			if f.conn == idx && f.Method != "ping" {
+				got = append(got, f.String())
+			}
+		case <-deadline:
+			t.Fatalf("%v 内连接 %d 只收到 %v，期望 %d 条请求", timeout, idx, got, n)
+		}
+	}
+	return got
+}
+
+// expectNoFrame 确认 d 内第 idx 个连接没有收到订阅请求
+func (s *wsServer) expectNoFrame(t *testing.T, idx int, d time.Duration) {
+	t.Helper()
+	deadline := time.After(d)
+	for {
+		select {
+		case f := <-s.frames:
+			if f.conn == idx && f.Method != "ping" {
+				t.Fatalf("连接 %d 收到多余的请求: %s", idx, f)
+			}
+		case <-deadline:
+			return
+		}
+	}
+}
+
+// expectPing 等待第 idx 个连接收到 ping，返回等待的时间
+func (s *wsServer) expectPing(t *testing.T, idx int, timeout time.Duration) time.Duration {
+	t.Helper()
+	start := time.Now()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case f := <-s.frames:
+			if f.conn == idx && f.Method == "ping" {
+				return time.Since(start)
+			}
+		case <-deadline:
+			t.Fatalf("%v 内连接 %d 没有收到 ping", timeout, idx)
+			return 0
+		}
+	}
+}
+
+func newTestClient(t *testing.T, s *wsServer) *WebSocketClient {
+	t.Helper()
+	c := NewWebSocketClient()
+	c.URL = s.url()
+	t.Cleanup(c.Close)
+	return c
+}
+
+func sorted(items []string) []string {
+	items = append([]string(nil), items...)
+	sort.Strings(items)
+	return items
+}
+
+func TestSubscribeWhileDisconnectedIsSentAfterConnect(t *testing.T) {
+	srv := newWSServer(t, true)
+	c := newTestClient(t, srv)
+
+	// 尚未连接时的订阅和取消订阅只修改订阅集合
+	for _, coin := range []string{"BTC", "ETH", "SOL"} {
+		if err := c.SubscribeCoin(coin); err != nil {
+			t.Fatalf("SubscribeCoin(%s) error = %v", coin, err)
+		}
+	}
+	if err := c.Unsubscribe("ETH"); err != nil {
+		t.Fatalf("Unsubscribe(ETH) error = %v", err)
+	}
+	if srv.connCount() != 0 {
+		t.Fatalf("StartListening 之前就建立了连接")
+	}
+
+	c.StartListening()
+	idx := srv.waitConn(t, 5*time.Second)
+	got := srv.expectFrames(t, idx, 2, 5*time.Second)
+	want := []string{"subscribe activeAssetCtx:BTC", "subscribe activeAssetCtx:SOL"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("连接后收到 %v，期望 %v", got, want)
+	}
+	// 已取消的 ETH 既不订阅也不需要取消订阅
+	srv.expectNoFrame(t, idx, 200*time.Millisecond)
+}
+
+func TestResubscribeAfterServerDrop(t *testing.T) {
+	srv := newWSServer(t, true)
+	c := newTestClient(t, srv)
+	c.SubscribeCoin("BTC")
+	c.SubscribeCoin("ETH")
+	c.StartListening()
+
+	first := srv.waitConn(t, 5*time.Second)
+	srv.expectFrames(t, first, 2, 5*time.Second)
+
+	// 服务端断开后新增的订阅与原有订阅一起在新连接上发送
+	srv.drop(first)
+	c.SubscribeCoin("SOL")
+
+	second := srv.waitConn(t, 5*time.Second)
+	got := sorted(srv.expectFrames(t, second, 3, 5*time.Second))
+	want := []string{"subscribe activeAssetCtx:BTC", "subscribe activeAssetCtx:ETH", "subscribe activeAssetCtx:SOL"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("重连后收到 %v，期望 %v", got, want)
+	}
+	srv.expectNoFrame(t, second, 200*time.Millisecond)
+}
+
+func TestConcurrentSubscribeAndClose(t *testing.T) {
+	srv := newWSServer(t, true)
+	c := newTestClient(t, srv)
+	c.StartListening()
+	srv.waitConn(t, 5*time.Second)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for j := 0; ; j++ {
+				coin := fmt.Sprintf("C%d_%d", i, j%5)
+				err := c.SubscribeCoin(coin)
+				if err == nil {
+					c.ListSubscriptions()
+					err = c.Unsubscribe(coin)
+				}
+				if errors.Is(err, ErrClosed) {
+					return
+				}
+				if err != nil {
+					t.Errorf("订阅 %s 返回意外的错误: %v", coin, err)
+					return
+				}
+			}
+		}()
+	}
+
+	close(start)
+	time.Sleep(50 * time.Millisecond)
+	c.Close()
+	wg.Wait()
+
+	if err := c.SubscribeCoin("BTC"); !errors.Is(err, ErrClosed) {
+		t.Errorf("关闭后 SubscribeCoin() error = %v，期望 ErrClosed", err)
+	}
+	if state := c.State(); state != StateClosed {
+		t.Errorf("关闭后 State() = %v，期望 closed", state)
+	}
+}
+
+func TestPingWithinInterval(t *testing.T) {
+	const interval = 200 * time.Millisecond
+	srv := newWSServer(t, true)
+	c := newTestClient(t, srv)
+	c.SetHeartbeat(interval, 5*time.Second)
+	c.StartListening()
+
+	idx := srv.waitConn(t, 5*time.Second)
+	for i := 0; i < 2; i++ {
+		if wait := srv.expectPing(t, idx, 5*interval); wait > interval+150*time.Millisecond {
+			t.Errorf("第 %d 次 ping 等待了 %v，期望在 ping 间隔 %v 内发送", i+1, wait, interval)
+		}
+	}
+}
+
+func TestReconnectAfterIdleTimeout(t *testing.T) {
+	// 服务端不回复 ping，客户端在空闲超时后判定连接失效并重连
+	srv := newWSServer(t, false)
+	c := newTestClient(t, srv)
+	c.SetHeartbeat(100*time.Millisecond, 300*time.Millisecond)
+	c.SubscribeCoin("BTC")
+	c.StartListening()
+
+	first := srv.waitConn(t, 5*time.Second)
+	srv.expectFrames(t, first, 1, 5*time.Second)
+
+	deadline := time.After(5 * time.Second)
+	for disconnected := false; !disconnected; {
+		select {
+		case event := <-c.StateChanges():
+			if event.State != StateDisconnected {
+				continue
+			}
+			var netErr interface{ Timeout() bool }
+			if !errors.As(event.Err, &netErr) || !netErr.Timeout() {
+				t.Fatalf("断开原因 = %v，期望空闲超时", event.Err)
+			}
+			disconnected = true
+		case <-deadline:
+			t.Fatalf("空闲超时后没有断开连接")
+		}
+	}
+
+	second := srv.waitConn(t, 5*time.Second)
+	if got := srv.expectFrames(t, second, 1, 5*time.Second); got[0] != "subscribe activeAssetCtx:BTC" {
+		t.Errorf("重连后收到 %v，期望重新订阅 BTC", got)
+	}
+}
+
+func TestSubscriptionStringIncludesAllFields(t *testing.T) {
+	base := Subscription{Type: "l2Book", Coin: "BTC"}
+	subs := []Subscription{
+		base,
+		{Type: "l2Book", Coin: "BTC", NSigFigs: 5},
+		{Type: "l2Book", Coin: "BTC", NSigFigs: 5, Mantissa: 2},
+		{Type: "userFills", User: "0xabc"},
+		{Type: "userFills", User: "0xabc", AggregateByTime: true},
+	}
+	seen := make(map[string]Subscription)
+	for _, sub := range subs {
+		s := sub.String()
+		if prev, ok := seen[s]; ok {
+			t.Errorf("%+v 与 %+v 的描述相同: %s", sub, prev, s)
+		}
+		seen[s] = sub
+	}
+	if got, want := subs[2].String(), "l2Book:BTC:nSigFigs=5:mantissa=2"; got != want {
+		t.Errorf("String() = %q，期望 %q", got, want)
+	}
+}
+
+func TestListSubscriptionsIsDeterministic(t *testing.T) {
+	want := []Subscription{
+		ActiveAssetCtx("BTC"),
+		ActiveAssetCtx("ETH"),
+		{Type: "candle", Coin: "ETH", Interval: "1m"},
+		{Type: "l2Book", Coin: "BTC"},
+		{Type: "l2Book", Coin: "BTC", NSigFigs: 5},
+		{Type: "l2Book", Coin: "BTC", NSigFigs: 5, Mantissa: 2},
+		{Type: "userFills", User: "0xabc"},
+		{Type: "userFills", User: "0xabc", AggregateByTime: true},
+	}
+	for i := 0; i < 20; i++ {
+		c := NewWebSocketClient()
+		// map 遍历顺序随机，多次以不同顺序插入
+		for j := range want {
+			if err := c.Subscribe(want[(i+j*3)%len(want)]); err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+		}
+		got := c.ListSubscriptions()
+		c.Close()
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("ListSubscriptions() = %v，期望 %v", got, want)
+		}
+	}
+}
+
+func TestSubscribeRejectsEmptyType(t *testing.T) {
+	c := NewWebSocketClient()
+	defer c.Close()
+	if err := c.Subscribe(Subscription{Coin: "BTC"}); err == nil {
+		t.Fatalf("Subscribe() 缺少类型时应返回错误")
+	}
+	if subs := c.ListSubscriptions(); len(subs) != 0 {
+		t.Errorf("ListSubscriptions() = %v，期望为空", subs)
+	}
+}
+
+func TestUnsubscribeFromSendsFrameWithoutReconnect(t *testing.T) {
+	srv := newWSServer(t, true)
+	c := newTestClient(t, srv)
+	book := Subscription{Type: "l2Book", Coin: "BTC", NSigFigs: 5}
+	trades := Subscription{Type: "trades", Coin: "ETH"}
+	c.StartListening()
+	idx := srv.waitConn(t, 5*time.Second)
+
+	// 已连接时订阅立即发送，包括 nSigFigs 等附加字段
+	c.Subscribe(book)
+	c.Subscribe(trades)
+	got := sorted(srv.expectFrames(t, idx, 2, 5*time.Second))
+	want := []string{"subscribe l2Book:BTC:nSigFigs=5", "subscribe trades:ETH"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("收到 %v，期望 %v", got, want)
+	}
+
+	if err := c.UnsubscribeFrom(book); err != nil {
+		t.Fatalf("UnsubscribeFrom() error = %v", err)
+	}
+	if got := srv.expectFrames(t, idx, 1, 5*time.Second); got[0] != "unsubscribe l2Book:BTC:nSigFigs=5" {
+		t.Fatalf("收到 %v，期望取消订阅 l2Book:BTC:nSigFigs=5", got)
+	}
+	srv.expectNoFrame(t, idx, 200*time.Millisecond)
+
+	if n := srv.connCount(); n != 1 {
+		t.Errorf("建立了 %d 个连接，取消订阅不应重连", n)
+	}
+	if got := srv.active(idx); strings.Join(got, ",") != "trades:ETH" {
+		t.Errorf("服务端当前订阅 %v，期望只剩 trades:ETH", got)
+	}
+	if subs := c.ListSubscriptions(); len(subs) != 1 || subs[0] != trades {
+		t.Errorf("ListSubscriptions() = %v，期望只剩 %v", subs, trades)
+	}
+}
+
+func TestUnsubscribeDuringReconnect(t *testing.T) {
+	srv := newWSServer(t, true)
+	c := newTestClient(t, srv)
+	c.SubscribeCoin("BTC")
+	c.SubscribeCoin("ETH")
+	c.StartListening()
+
+	first := srv.waitConn(t, 5*time.Second)
+	srv.expectFrames(t, first, 2, 5*time.Second)
+
+	// 连接断开、尚未重连时取消订阅不应写入已关闭的连接
+	srv.drop(first)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				c.SubscribeCoin("DOGE")
+				if err := c.Unsubscribe("DOGE"); err != nil {
+					t.Errorf("Unsubscribe() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	if err := c.Unsubscribe("ETH"); err != nil {
+		t.Fatalf("Unsubscribe() error = %v", err)
+	}
+	c.SubscribeCoin("SOL")
+	wg.Wait()
+
+	// 新连接上只发送当前订阅，已取消的币种不再出现
+	second := srv.waitConn(t, 5*time.Second)
+	got := sorted(srv.expectFrames(t, second, 2, 5*time.Second))
+	want := []string{"subscribe activeAssetCtx:BTC", "subscribe activeAssetCtx:SOL"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("重连后收到 %v，期望 %v", got, want)
+	}
+	srv.expectNoFrame(t, second, 200*time.Millisecond)
+}
+
+func TestSubscriptionChurnDuringServerDrops(t *testing.T) {
+	srv := newWSServer(t, true)
+	c := newTestClient(t, srv)
+	c.SubscribeCoin("BTC")
+	c.StartListening()
+	srv.waitConn(t, 5*time.Second)
+
+	coins := []string{"ETH", "SOL", "DOGE", "ARB"}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, coin := range coins {
+		wg.Add(1)
+		go func(coin string) {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if j%2 == 0 {
+					c.SubscribeCoin(coin)
+				} else if err := c.Unsubscribe(coin); err != nil {
+					t.Errorf("Unsubscribe(%s) error = %v", coin, err)
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}(coin)
+	}
+
+	// 订阅变化的同时服务端反复断开连接
+	var dropped int
+	for i := 0; i < 3; i++ {
+		time.Sleep(300 * time.Millisecond)
+		dropped = srv.connCount()
+		srv.dropAll()
+	}
+	close(stop)
+	wg.Wait()
+
+	// 最后一次断开后的新连接上，服务端的订阅应与客户端记录一致
+	var want []string
+	for _, sub := range c.ListSubscriptions() {
+		want = append(want, sub.String())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var got []string
+		if n := srv.connCount(); n > dropped {
+			got = srv.active(n - 1)
+			if strings.Join(got, ",") == strings.Join(want, ",") {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("服务端订阅 %v，期望与客户端一致 %v", got, want)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,69 @@
+package i18n
+
+// en 英文文案
+var en = map[string]string{
+	// 仓位分布表
+	"report.title":        "📊 Position Data",
+	"report.heading":      "%s Position Distribution",
+	"report.oracle_price": "%s Oracle price: %s",
+	"report.long_total":   "%s total Long: %s",
+	"report.short_total":  "%s total Short: %s",
+	"report.long_header":  "💰Price   🟢Long(%s)",
+	"report.short_header": "💰Price     🔴Short(%s)",
+	"report.trade_link":   "📈 More %s trading data",
+	"report.zoom":         "Zoom %+d: price window ±%.2f%%, bin %v",
+	"report.stale":        "⚠️ price %s old",
+	"report.failed":       "⚠️ Failed to fetch data: %s",
+
+	// 图表说明
+	"caption.title":        "📊 %s Position Distribution",
+	"caption.oracle_price": "Oracle price:",
+
+	// 邮件日报
+	"digest.subject": "Hyperliquid Position Digest",
+
+	// 命令菜单
+	"command.price":       "Show oracle prices, e.g. /price HYPE",
+	"command.table":       "Show the position table, e.g. /table BTC",
+	"command.chart":       "Show the position chart, e.g. /chart ETH",
+	"command.coins":       "List supported coins",
+	"command.subscribe":   "Get private updates for a coin, e.g. /subscribe BTC",
+	"command.unsubscribe": "Stop private updates, e.g. /unsubscribe BTC",
+	"command.mysubs":      "List my subscriptions",
+	"command.status":      "Show bot status",
+	"command.help":        "Show help",
+
+	// 命令回复
+	"help.title":          "🤖 Available commands",
+	"price.title":         "💰 Oracle Prices",
+	"price.updated":       "%s (updated %s ago)",
+	"price.stale":         "⚠️ stale",
+	"coins.title":         "📋 Supported Coins",
+	"status.title":        "📡 Status",
+	"status.uptime":       "Uptime: %s",
+	"status.next_run":     "Next push: %s",
+	"subscribe.added":     "✅ Subscribed to %s, the position table will be sent to you privately on schedule",
+	"subscribe.exists":    "ℹ️ Already subscribed to %s",
+	"subscribe.start":     "Please send /start to the bot in a private chat first, otherwise it cannot message you",
+	"unsubscribe.removed": "✅ Unsubscribed from %s",
+	"unsubscribe.missing": "ℹ️ Not subscribed to %s",
+	"mysubs.title":        "🔔 My Subscriptions",
+	"mysubs.empty":        "📭 No subscriptions yet, use /subscribe COIN to get private updates",
+
+	// 运维告警
+	"alert.price_stale":     "⚠️ %s oracle price not updated for %v (threshold %v), check the WebSocket connection",
+	"alert.price_missing":   "⚠️ No %s oracle price received for over %v, check the WebSocket connection",
+	"alert.price_recovered": "✅ %s oracle price updates resumed: %s",
+
+	// 仓位分布表键盘
+	"keyboard.zoom_in":  "🔍 Zoom in",
+	"keyboard.zoom_out": "🔎 Zoom out",
+	"keyboard.refresh":  "🔄 Refresh",
+	"keyboard.expired":  "This button has expired",
+
+	// 错误提示
+	"error.prefix":       "⚠️ %s",
+	"error.need_coin":    "Please specify a coin, e.g. /%s %s",
+	"error.unknown_coin": "Unsupported coin %s, available coins: %s",
+	"error.no_sender":    "Unable to identify the sender",
+}
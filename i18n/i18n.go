@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 内置语言
+const (
+	ZH = "zh"
+	EN = "en"
+)
+
+// Default 默认语言，其他语言缺少的文案也回退到默认语言
+const Default = ZH
+
+var (
+	mu       sync.RWMutex
+	catalogs = map[string]map[string]string{
+		ZH: zh,
+		EN: en,
+	}
+)
+
+// Register 注册一种语言的文案，已存在时合并并覆盖同名文案；新增语言只需提供与 zh 相同的键
+func Register(lang string, messages map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	catalog := catalogs[lang]
+	if catalog == nil {
+		catalog = make(map[string]string, len(messages))
+		catalogs[lang] = catalog
+	}
+	for key, msg := range messages {
+		catalog[key] = msg
+	}
+}
+
+// Supported 判断是否支持该语言
+func Supported(lang string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Languages 返回支持的语言，按名称排序
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// T 返回 lang 语言的文案，有参数时按 fmt.Sprintf 格式化。
+// 缺少该文案时回退到默认语言，仍然缺少时返回 key 本身
+func T(lang, key string, args ...interface{}) string {
+	mu.RLock()
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		msg, ok = catalogs[Default][key]
+	}
+	mu.RUnlock()
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Match 将 Telegram 的 language_code（IETF 语言标签，如 en-US、zh-hans）匹配到支持的语言，无法匹配时返回空
+func Match(code string) string {
+	code = strings.ToLower(code)
+	if Supported(code) {
+		return code
+	}
+	if i := strings.IndexAny(code, "-_"); i > 0 && Supported(code[:i]) {
+		return code[:i]
+	}
+	return ""
+}
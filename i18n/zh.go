@@ -0,0 +1,69 @@
+package i18n
+
+// zh 中文文案，同时作为其他语言缺少文案时的回退
+var zh = map[string]string{
+	// 仓位分布表
+	"report.title":        "📊 Position Data",
+	"report.heading":      "%s 仓位分布",
+	"report.oracle_price": "当前 %s Oracle 价格: %s",
+	"report.long_total":   "统计 %s Long 总数: %s",
+	"report.short_total":  "统计 %s Short 总数: %s",
+	"report.long_header":  "💰Price   🟢Long(%s)",
+	"report.short_header": "💰Price     🔴Short(%s)",
+	"report.trade_link":   "📈 查看更多 %s 交易数据",
+	"report.zoom":         "缩放 %+d：价格窗口 ±%.2f%%，分箱 %v",
+	"report.stale":        "⚠️ 价格已 %s 未更新",
+	"report.failed":       "⚠️ 获取数据失败: %s",
+
+	// 图表说明
+	"caption.title":        "📊 %s 仓位分布",
+	"caption.oracle_price": "Oracle 价格:",
+
+	// 邮件日报
+	"digest.subject": "Hyperliquid 仓位日报",
+
+	// 命令菜单
+	"command.price":       "查看 Oracle 价格，如 /price HYPE",
+	"command.table":       "查看仓位分布表，如 /table BTC",
+	"command.chart":       "查看仓位分布图，如 /chart ETH",
+	"command.coins":       "查看支持的币种",
+	"command.subscribe":   "订阅币种私信推送，如 /subscribe BTC",
+	"command.unsubscribe": "取消订阅，如 /unsubscribe BTC",
+	"command.mysubs":      "查看我的订阅",
+	"command.status":      "查看运行状态",
+	"command.help":        "查看帮助",
+
+	// 命令回复
+	"help.title":          "🤖 可用命令",
+	"price.title":         "💰 Oracle 价格",
+	"price.updated":       "%s（%s 前更新）",
+	"price.stale":         "⚠️ 已过期",
+	"coins.title":         "📋 支持的币种",
+	"status.title":        "📡 运行状态",
+	"status.uptime":       "已运行: %s",
+	"status.next_run":     "下次推送: %s",
+	"subscribe.added":     "✅ 已订阅 %s，将按推送计划私信发送仓位分布表",
+	"subscribe.exists":    "ℹ️ 已订阅过 %s",
+	"subscribe.start":     "请先私聊机器人发送 /start，否则无法接收私信",
+	"unsubscribe.removed": "✅ 已取消订阅 %s",
+	"unsubscribe.missing": "ℹ️ 未订阅 %s",
+	"mysubs.title":        "🔔 我的订阅",
+	"mysubs.empty":        "📭 暂无订阅，使用 /subscribe 币种 订阅私信推送",
+
+	// 运维告警
+	"alert.price_stale":     "⚠️ %s Oracle 价格已 %v 未更新（阈值 %v），请检查 WebSocket 连接",
+	"alert.price_missing":   "⚠️ 未收到 %s 的 Oracle 价格（已超过 %v），请检查 WebSocket 连接",
+	"alert.price_recovered": "✅ %s Oracle 价格已恢复更新: %s",
+
+	// 仓位分布表键盘
+	"keyboard.zoom_in":  "🔍 放大",
+	"keyboard.zoom_out": "🔎 缩小",
+	"keyboard.refresh":  "🔄 刷新",
+	"keyboard.expired":  "按钮已失效",
+
+	// 错误提示
+	"error.prefix":       "⚠️ %s",
+	"error.need_coin":    "请指定币种，如 /%s %s",
+	"error.unknown_coin": "不支持的币种 %s，可用币种: %s",
+	"error.no_sender":    "无法识别发送者",
+}
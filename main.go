@@ -1,78 +1,230 @@
 package main
 
 import (
+	"context"
 	hyperliquid "hyper-notify-bot/hyperLiquid"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
+	"hyper-notify-bot/command"
 	"hyper-notify-bot/config"
+	"hyper-notify-bot/formatter"
+	"hyper-notify-bot/i18n"
 	//"hyper-notify-bot/logger"
+	"hyper-notify-bot/notifier"
 	"hyper-notify-bot/scheduler"
 	"hyper-notify-bot/service"
 	"hyper-notify-bot/telegram"
 )
 
+// configWatchInterval 检查配置文件是否修改的间隔
+const configWatchInterval = 5 * time.Second
+
+// coinCheckTimeout 查询 Hyperliquid 币种列表的超时时间
+const coinCheckTimeout = 10 * time.Second
+
 func main() {
 	// 初始化日志
 	//logger.SetupLogger()
 
-	// 加载配置
-	cfg, err := config.LoadConfig()
+	// 加载配置，同时确认 Hyperliquid 确实上线了配置的币种
+	cfg, err := config.LoadConfig(checkCoins)
 	if err != nil {
 		log.Fatalf("配置加载失败: %v", err)
 	}
+	log.Printf("配置加载完成:\n%s", cfg.Summary())
+
+	// 加载消息模板，模板错误在启动时暴露
+	templates, err := formatter.LoadTemplates(cfg.TemplatesDir)
+	if err != nil {
+		log.Fatalf("消息模板加载失败: %v", err)
+	}
+	formatter.SetTemplates(templates)
 
 	// 创建 Hyperliquid WebSocket 客户端
 	wsClient := hyperliquid.NewWebSocketClient()
-	if err := wsClient.Connect(); err != nil {
-		log.Fatalf("WebSocket 连接失败: %v", err)
-	}
+	wsClient.SetHeartbeat(cfg.WSPingInterval, cfg.WSIdleTimeout)
 	defer wsClient.Close()
 
-	// 订阅 HYPE 币种
-	if err := wsClient.Subscribe("HYPE"); err != nil {
-		log.Fatalf("订阅 HYPE 失败: %v", err)
-	}
-	if err := wsClient.Subscribe("BTC"); err != nil {
-		log.Fatalf("订阅 HYPE 失败: %v", err)
-	}
-	if err := wsClient.Subscribe("SOL"); err != nil {
-		log.Fatalf("订阅 HYPE 失败: %v", err)
-	}
-	if err := wsClient.Subscribe("ETH"); err != nil {
-		log.Fatalf("订阅 HYPE 失败: %v", err)
+	// 订阅配置中的所有币种，连接建立（包括断线重连）后自动发送订阅
+	for _, coin := range cfg.Coins {
+		if err := wsClient.SubscribeCoin(coin); err != nil {
+			log.Fatalf("订阅 %s 失败: %v", coin, err)
+		}
 	}
 
-	// 开始监听 WebSocket
+	// 开始监听 WebSocket，连接失败时按指数退避重连
 	wsClient.StartListening()
 
-	log.Println("已连接 Hyperliquid WebSocket 并订阅 HYPE")
+	log.Printf("正在连接 Hyperliquid WebSocket 并订阅 %s", strings.Join(cfg.Coins, ","))
 
 	// 创建数据服务
 	dataService, err := service.NewDataService(cfg)
 	if err != nil {
 		log.Fatalf("创建数据服务失败: %v", err)
 	}
+	dataService.WsClient = wsClient
 	defer dataService.Close()
 
 	// 创建Telegram机器人
 	bot := telegram.NewTelegramBot(cfg.TelegramToken, cfg.TelegramChatID, cfg.TelegramProxy)
+	bot.APIBaseURL = cfg.TelegramAPIURL
 
 	// 创建定时任务调度器
-	cronScheduler := scheduler.NewCronScheduler(bot, cfg, dataService, wsClient)
+	// 创建推送渠道（Telegram、Discord、Slack、Webhook）
+	notifiers := notifier.NewFactory(bot, dataService)
+
+	cronScheduler := scheduler.NewCronScheduler(notifiers, cfg, dataService)
 	cronScheduler.Start()
 	defer cronScheduler.Stop()
 
+	// 接收用户命令
+	updatesCtx, stopUpdates := context.WithCancel(context.Background())
+	defer stopUpdates()
+	if cfg.TelegramUpdateMode != config.UpdateModeOff {
+		handler := command.NewHandler(bot, dataService, cronScheduler)
+		// 群组中只响应 @ 本机器人的命令，提前获取用户名，失败时在收到命令时重试
+		if username, err := bot.Username(updatesCtx); err != nil {
+			log.Printf("%v", err)
+		} else {
+			log.Printf("Telegram 机器人: @%s", username)
+		}
+		// 默认菜单使用配置的语言，其余语言的用户按 Telegram 客户端语言显示
+		if err := bot.SetMyCommands(updatesCtx, command.Commands(cfg.Locale), ""); err != nil {
+			log.Printf("设置命令菜单失败: %v", err)
+		}
+		for _, lang := range i18n.Languages() {
+			if err := bot.SetMyCommands(updatesCtx, command.Commands(lang), lang); err != nil {
+				log.Printf("设置 %s 命令菜单失败: %v", lang, err)
+			}
+		}
+
+		switch cfg.TelegramUpdateMode {
+		case config.UpdateModePolling:
+			// 已注册的 webhook 会导致 getUpdates 冲突
+			if err := bot.DeleteWebhook(updatesCtx); err != nil {
+				log.Printf("删除 webhook 失败: %v", err)
+			}
+			go bot.PollUpdates(updatesCtx, handler.HandleUpdate)
+			log.Println("已开启 Telegram 命令长轮询")
+		case config.UpdateModeWebhook:
+			go func() {
+				if err := bot.ServeWebhook(updatesCtx, cfg.Webhook, handler.HandleUpdate); err != nil {
+					log.Printf("Telegram webhook 服务停止: %v", err)
+				}
+			}()
+		}
+	}
+
 	log.Println("Telegram表格数据定时推送系统已启动")
-	log.Printf("每 %v 发送一次数据", cfg.Interval)
 	log.Printf("数据源: MongoDB (%s/%s)", cfg.MongoDB, cfg.MongoCollection)
 
-	// 等待中断信号
+	// 监听配置文件变化，支持热更新
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	fileChanged := config.Watch(watchCtx, cfg.File, configWatchInterval)
+
+	// 等待中断信号，SIGHUP 触发配置重新加载
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	for {
+		select {
+		case sig := <-sigChan:
+			if sig == syscall.SIGHUP {
+				log.Println("接收到 SIGHUP，重新加载配置")
+				cfg = reloadConfig(cfg, wsClient, dataService, cronScheduler)
+				continue
+			}
+			log.Println("接收到中断信号，程序正在退出...")
+			return
+		case <-fileChanged:
+			log.Printf("配置文件 %s 已修改，重新加载配置", cfg.File)
+			cfg = reloadConfig(cfg, wsClient, dataService, cronScheduler)
+		case event := <-wsClient.StateChanges():
+			logConnState(event)
+		}
+	}
+}
+
+// reloadConfig 重新加载配置并应用到运行中的组件，失败时返回原配置
+func reloadConfig(cfg *config.Config,
+	wsClient *hyperliquid.WebSocketClient,
+	dataService *service.DataService,
+	cronScheduler *scheduler.CronScheduler) *config.Config {
+	newCfg, err := config.Load(cfg.File, checkCoins)
+	if err != nil {
+		log.Printf("配置重新加载失败，继续使用原配置: %v", err)
+		return cfg
+	}
+
+	// 模板文件可能单独修改，每次重新加载配置时都重新加载模板，所有检查通过后才替换
+	templates, err := formatter.LoadTemplates(newCfg.TemplatesDir)
+	if err != nil {
+		log.Printf("消息模板加载失败，继续使用原模板: %v", err)
+	}
+
+	changes := config.Diff(cfg, newCfg)
+	if changes.Empty() {
+		log.Println("配置无变化")
+		if templates != nil {
+			formatter.SetTemplates(templates)
+		}
+		return cfg
+	}
+	log.Printf("配置变化: %s", changes)
 
-	log.Println("接收到中断信号，程序正在退出...")
+	if changes.ScheduleChanged {
+		if err := cronScheduler.Reload(newCfg); err != nil {
+			log.Printf("配置重新加载失败，继续使用原配置: %v", err)
+			return cfg
+		}
+	} else {
+		cronScheduler.SetConfig(newCfg)
+	}
+	if templates != nil {
+		formatter.SetTemplates(templates)
+	}
+	dataService.SetConfig(newCfg)
+	wsClient.SetHeartbeat(newCfg.WSPingInterval, newCfg.WSIdleTimeout)
+
+	for _, coin := range changes.AddedCoins {
+		if err := wsClient.SubscribeCoin(coin); err != nil {
+			log.Printf("订阅 %s 失败: %v", coin, err)
+		}
+	}
+	for _, coin := range changes.RemovedCoins {
+		if err := wsClient.Unsubscribe(coin); err != nil {
+			log.Printf("取消订阅 %s 失败: %v", coin, err)
+		}
+	}
+
+	log.Printf("配置已重新加载:\n%s", newCfg.Summary())
+	return newCfg
+}
+
+// checkCoins 返回 coins 中 Hyperliquid 未上线的币种，查询失败时只记录警告，不影响配置加载
+func checkCoins(coins []string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), coinCheckTimeout)
+	defer cancel()
+	unknown, err := hyperliquid.UnknownCoins(ctx, coins)
+	if err != nil {
+		log.Printf("警告: %v，跳过币种检查", err)
+		return nil, nil
+	}
+	return unknown, nil
+}
+
+// logConnState 记录 WebSocket 连接状态变化
+func logConnState(event hyperliquid.StateEvent) {
+	switch event.State {
+	case hyperliquid.StateConnected:
+		log.Printf("Hyperliquid WebSocket 已连接")
+	case hyperliquid.StateDisconnected:
+		log.Printf("Hyperliquid WebSocket 连接断开（连续第 %d 次），%v 后重连: %v", event.Attempt, event.RetryIn.Round(time.Millisecond), event.Err)
+	}
 }
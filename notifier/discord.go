@@ -0,0 +1,120 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"strings"
+
+	"hyper-notify-bot/config"
+	"hyper-notify-bot/formatter"
+)
+
+// Discord embed 的长度限制
+const (
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+	discordEmbedColor       = 0x26a69a
+)
+
+// codeFence Markdown 代码块的开始和结束标记
+const codeFence = "```"
+
+// DiscordNotifier 推送到 Discord 频道的 Webhook
+type DiscordNotifier struct {
+	webhook
+}
+
+type discordPayload struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string        `json:"title,omitempty"`
+	Description string        `json:"description"`
+	Color       int           `json:"color"`
+	Image       *discordImage `json:"image,omitempty"`
+}
+
+type discordImage struct {
+	URL string `json:"url"`
+}
+
+func (n *DiscordNotifier) Name() string {
+	return n.name("Discord")
+}
+
+// Send 以 embed 发送消息，开启图表时以附件上传图片并显示在 embed 中
+func (n *DiscordNotifier) Send(ctx context.Context, msg Message) error {
+	f := formatter.Markdown
+	if n.Chat.Format == config.FormatText {
+		f = formatter.Text
+	}
+	description := msg.Body(f, n.Chat.Locale)
+
+	image := msg.Image
+	if n.Chat.Chart == config.ChartOff {
+		image = nil
+	}
+	if image != nil && n.Chat.Chart == config.ChartOnly {
+		description = image.Caption(f, n.Chat.Locale)
+	}
+
+	embed := discordEmbed{
+		Title:       truncate(msg.Title(formatter.Text, n.Chat.Locale), discordTitleLimit),
+		Description: truncate(description, discordDescriptionLimit),
+		Color:       discordEmbedColor,
+	}
+	if image == nil {
+		return n.postJSON(ctx, discordPayload{Embeds: []discordEmbed{embed}})
+	}
+
+	// 图片以 multipart 上传，embed 通过 attachment:// 引用
+	embed.Image = &discordImage{URL: "attachment://" + image.Name}
+	payload, err := json.Marshal(discordPayload{Embeds: []discordEmbed{embed}})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %v", err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("payload_json", string(payload)); err != nil {
+		return fmt.Errorf("构建请求失败: %v", err)
+	}
+	part, err := writer.CreateFormFile("files[0]", image.Name)
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %v", err)
+	}
+	if _, err := part.Write(image.Data); err != nil {
+		return fmt.Errorf("构建请求失败: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("构建请求失败: %v", err)
+	}
+	return n.post(ctx, writer.FormDataContentType(), body.Bytes())
+}
+
+// truncate 按字符数截断，超出时以省略号结尾。多行文本截断到最后一个完整的行，
+// 截断处位于代码块内时补上结束标记，避免后续内容按代码块显示
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+
+	// 预留结束代码块和省略号的位置
+	const closing = "\n" + codeFence + "\n…"
+	reserve := len([]rune(closing))
+	cut := string(runes[:max(limit-reserve, 0)])
+	i := strings.LastIndex(cut, "\n")
+	if i <= 0 {
+		return string(runes[:limit-1]) + "…"
+	}
+	cut = cut[:i]
+	if strings.Count(cut, codeFence)%2 == 1 {
+		cut += "\n" + codeFence
+	}
+	return cut + "\n…"
+}
@@ -0,0 +1,37 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	table := "BTC 仓位分布\n```\n" + strings.Repeat("95000  12.34 (50%)\n", 10) + "```\n合计"
+
+	tests := []struct {
+		name  string
+		s     string
+		limit int
+		want  string
+	}{
+		{"未超出", "BTC 仓位分布", 20, "BTC 仓位分布"},
+		{"单行", "BTC 仓位分布日报", 6, "BTC 仓…"},
+		{"代码块内截断", table, 60, "BTC 仓位分布\n```\n95000  12.34 (50%)\n95000  12.34 (50%)\n```\n…"},
+		{"代码块前截断", "第一行\n第二行\n```\n95000\n```", 12, "第一行\n…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncate(tt.s, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncate() = %q，期望 %q", got, tt.want)
+			}
+			if n := utf8.RuneCountInString(got); n > tt.limit {
+				t.Errorf("截断后 %d 个字符，超过上限 %d", n, tt.limit)
+			}
+			if strings.Count(got, codeFence)%2 != 0 {
+				t.Errorf("截断后代码块未结束: %q", got)
+			}
+		})
+	}
+}
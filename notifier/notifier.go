@@ -0,0 +1,139 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hyper-notify-bot/config"
+	"hyper-notify-bot/formatter"
+	"hyper-notify-bot/telegram"
+)
+
+// Message 一条推送消息，各渠道按自身支持的格式渲染内容
+type Message struct {
+	Coin  string
+	Title formatter.Content // 标题，如 "BTC 仓位分布"，按纯文本渲染
+	Body  formatter.Content // 消息内容
+	Image *Image            // 可选的图表
+}
+
+// Image 随消息发送的图片
+type Image struct {
+	Name    string            // 文件名，如 btc.png
+	Data    []byte            // PNG 数据
+	Caption formatter.Content // 图片说明
+}
+
+// Notifier 推送渠道
+type Notifier interface {
+	// Name 返回用于日志的渠道名称，不包含密钥
+	Name() string
+	// Send 发送消息
+	Send(ctx context.Context, msg Message) error
+}
+
+// Factory 根据路由表创建推送渠道
+type Factory struct {
+	Bot        *telegram.TelegramBot
+	Dashboards DashboardStore // Telegram 原地更新消息的存储
+	Client     *http.Client   // Webhook 渠道使用的 HTTP 客户端
+}
+
+// NewFactory 创建推送渠道工厂
+func NewFactory(bot *telegram.TelegramBot, dashboards DashboardStore) *Factory {
+	return &Factory{
+		Bot:        bot,
+		Dashboards: dashboards,
+		Client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ForRoute 返回路由表中一个推送目标对应的渠道
+func (f *Factory) ForRoute(cfg *config.Config, chat config.ChatConfig) (Notifier, error) {
+	switch chat.Type {
+	case config.RouteTelegram, "":
+		return &TelegramNotifier{
+			Bot:        f.Bot,
+			Dashboards: f.Dashboards,
+			Target:     telegram.Target{ChatID: chat.ID, ThreadID: chat.ThreadID},
+			Chat:       chat,
+			Config:     cfg,
+		}, nil
+	case config.RouteDiscord:
+		return &DiscordNotifier{webhook: f.webhook(cfg, chat)}, nil
+	case config.RouteSlack:
+		return &SlackNotifier{webhook: f.webhook(cfg, chat)}, nil
+	case config.RouteWebhook:
+		return &WebhookNotifier{webhook: f.webhook(cfg, chat)}, nil
+	default:
+		return nil, fmt.Errorf("不支持的推送渠道: %s", chat.Type)
+	}
+}
+
+// ForUser 返回私信 Telegram 用户的渠道，用户私聊的 chat_id 与用户 ID 相同；locale 为空时使用默认语言
+func (f *Factory) ForUser(cfg *config.Config, userID int64, locale string) Notifier {
+	if locale == "" {
+		locale = cfg.Locale
+	}
+	chat := config.ChatConfig{
+		Type:   config.RouteTelegram,
+		ID:     strconv.FormatInt(userID, 10),
+		Format: config.FormatHTML,
+		Mode:   config.MessageModePost,
+		Chart:  config.ChartOff,
+		Locale: locale,
+	}
+	return &TelegramNotifier{
+		Bot:    f.Bot,
+		Target: telegram.Target{ChatID: chat.ID},
+		Chat:   chat,
+		Config: cfg,
+	}
+}
+
+// ForAlert 返回接收运维告警的 Telegram 渠道（cfg.AlertChatID），使用默认语言
+func (f *Factory) ForAlert(cfg *config.Config) Notifier {
+	chat := config.ChatConfig{
+		Type:   config.RouteTelegram,
+		ID:     cfg.AlertChatID,
+		Format: config.FormatHTML,
+		Mode:   config.MessageModePost,
+		Chart:  config.ChartOff,
+		Locale: cfg.Locale,
+	}
+	return &TelegramNotifier{
+		Bot:    f.Bot,
+		Target: telegram.Target{ChatID: chat.ID},
+		Chat:   chat,
+		Config: cfg,
+	}
+}
+
+// WantsImage 判断推送目标是否需要图表
+func WantsImage(chat config.ChatConfig) bool {
+	if chat.Chart == config.ChartOff || chat.Chart == "" {
+		return false
+	}
+	switch chat.Type {
+	case config.RouteSlack:
+		// Slack Incoming Webhook 不支持上传文件
+		return false
+	case config.RouteTelegram, "":
+		// 原地更新的消息无法附带图片
+		return chat.Mode != config.MessageModeEdit
+	default:
+		return true
+	}
+}
+
+func (f *Factory) webhook(cfg *config.Config, chat config.ChatConfig) webhook {
+	return webhook{
+		Client: f.Client,
+		URL:    chat.URL,
+		Chat:   chat,
+		Config: cfg,
+	}
+}
@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"context"
+
+	"hyper-notify-bot/config"
+	"hyper-notify-bot/formatter"
+)
+
+// SlackNotifier 推送到 Slack Incoming Webhook，不支持图片
+type SlackNotifier struct {
+	webhook
+}
+
+type slackPayload struct {
+	Text   string `json:"text"`
+	Mrkdwn bool   `json:"mrkdwn"`
+}
+
+func (n *SlackNotifier) Name() string {
+	return n.name("Slack")
+}
+
+// Send 以 mrkdwn 发送消息，消息格式为 text 时发送纯文本
+func (n *SlackNotifier) Send(ctx context.Context, msg Message) error {
+	if n.Chat.Format == config.FormatText {
+		return n.postJSON(ctx, slackPayload{Text: formatter.Slack.Escape(msg.Body(formatter.Text, n.Chat.Locale))})
+	}
+	return n.postJSON(ctx, slackPayload{Text: msg.Body(formatter.Slack, n.Chat.Locale), Mrkdwn: true})
+}
@@ -0,0 +1,205 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyper-notify-bot/config"
+	"hyper-notify-bot/formatter"
+)
+
+// SMTPNotifier 通过 SMTP 发送邮件，正文包含 HTML 和纯文本两个版本
+type SMTPNotifier struct {
+	Email  config.EmailConfig
+	Config *config.Config
+}
+
+// ForEmail 返回邮件日报的渠道
+func (f *Factory) ForEmail(cfg *config.Config) Notifier {
+	return &SMTPNotifier{Email: cfg.Email, Config: cfg}
+}
+
+func (n *SMTPNotifier) Name() string {
+	return "SMTP " + net.JoinHostPort(n.Email.Host, strconv.Itoa(n.Email.Port))
+}
+
+// Send 以消息标题为邮件主题发送给所有收件人，失败时按配置的次数重试
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	body, err := n.buildMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= n.Config.RetryCount; attempt++ {
+		if lastErr = n.send(ctx, body); lastErr == nil {
+			return nil
+		}
+		log.Printf("邮件发送失败 (尝试 %d/%d): %v", attempt, n.Config.RetryCount, lastErr)
+		// 5xx 为永久性错误（如收件人不存在、认证失败），不再重试
+		var protoErr *textproto.Error
+		if errors.As(lastErr, &protoErr) && protoErr.Code >= 500 {
+			break
+		}
+		if attempt == n.Config.RetryCount {
+			break
+		}
+
+		select {
+		case <-time.After(n.Config.RetryDelay):
+		case <-ctx.Done():
+			return fmt.Errorf("发送被取消: %v", ctx.Err())
+		}
+	}
+	return fmt.Errorf("发送失败，已达最大重试次数: %w", lastErr)
+}
+
+// buildMessage 生成 multipart/alternative 邮件，纯文本在前、HTML 在后
+func (n *SMTPNotifier) buildMessage(msg Message) ([]byte, error) {
+	locale := n.Config.Locale
+	title := msg.Title(formatter.Text, locale)
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", n.Email.From)
+	header("To", strings.Join(n.Email.To, ", "))
+	header("Subject", mime.BEncoding.Encode("UTF-8", title))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(n.Email.From))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `multipart/alternative; boundary="`+writer.Boundary()+`"`)
+	buf.WriteString("\r\n")
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.Body(formatter.Text, locale)},
+		{"text/html; charset=UTF-8", formatter.HTMLToEmail(title, msg.Body(formatter.HTML, locale))},
+	}
+	for _, p := range parts {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("生成邮件失败: %v", err)
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(strings.ReplaceAll(p.content, "\n", "\r\n"))); err != nil {
+			return nil, fmt.Errorf("生成邮件失败: %v", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("生成邮件失败: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("生成邮件失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// send 建立 SMTP 连接并发送一次，连接的读写超时跟随 ctx
+func (n *SMTPNotifier) send(ctx context.Context, body []byte) error {
+	addr := net.JoinHostPort(n.Email.Host, strconv.Itoa(n.Email.Port))
+	tlsConfig := &tls.Config{ServerName: n.Email.Host}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if n.Email.TLS == config.EmailTLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.Email.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接 SMTP 服务器失败: %v", err)
+	}
+	defer client.Close()
+
+	if n.Email.TLS == config.EmailTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP 服务器不支持 STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS 失败: %w", err)
+		}
+	}
+	if n.Email.Username != "" {
+		// PlainAuth 只允许在 TLS 连接或 localhost 上发送密码
+		if err := client.Auth(smtp.PlainAuth("", n.Email.Username, n.Email.Password, n.Email.Host)); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %w", err)
+		}
+	}
+
+	from, err := mail.ParseAddress(n.Email.From)
+	if err != nil {
+		return fmt.Errorf("发件人地址无效: %v", err)
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("MAIL FROM 失败: %w", err)
+	}
+	for _, to := range n.Email.To {
+		rcpt, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("收件人地址无效: %v", err)
+		}
+		if err := client.Rcpt(rcpt.Address); err != nil {
+			return fmt.Errorf("RCPT TO %s 失败: %w", rcpt.Address, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA 失败: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("写入邮件失败: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return client.Quit()
+}
+
+// messageID 生成 Message-ID，域名取发件人地址的域名
+func messageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if i := strings.LastIndex(addr.Address, "@"); i >= 0 {
+			domain = addr.Address[i+1:]
+		}
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
@@ -0,0 +1,265 @@
+package notifier
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hyper-notify-bot/config"
+	"hyper-notify-bot/formatter"
+)
+
+// smtpStub 最小化的 SMTP 服务端，记录收到的命令和邮件内容
+type smtpStub struct {
+	listener net.Listener
+	startTLS bool   // 是否在 EHLO 中声明 STARTTLS
+	rcpt     string // RCPT TO 的响应，为空时返回 250
+
+	mu       sync.Mutex
+	conns    int
+	commands []string
+	messages [][]byte
+}
+
+func newSMTPStub(t *testing.T, startTLS bool, rcpt string) *smtpStub {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	s := &smtpStub{listener: ln, startTLS: startTLS, rcpt: rcpt}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpStub) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStub) handle(conn net.Conn) {
+	defer conn.Close()
+	r := textproto.NewReader(bufio.NewReader(conn))
+	reply := func(lines ...string) {
+		for _, line := range lines {
+			io.WriteString(conn, line+"\r\n")
+		}
+	}
+
+	reply("220 stub ESMTP")
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.Fields(line + " ")[0])
+		s.mu.Lock()
+		s.commands = append(s.commands, verb)
+		s.mu.Unlock()
+
+		switch verb {
+		case "EHLO":
+			if s.startTLS {
+				reply("250-stub", "250-STARTTLS", "250 8BITMIME")
+			} else {
+				reply("250-stub", "250 8BITMIME")
+			}
+		case "HELO", "MAIL", "RSET", "NOOP":
+			reply("250 OK")
+		case "STARTTLS":
+			reply("454 TLS not available")
+		case "RCPT":
+			if s.rcpt != "" {
+				reply(s.rcpt)
+			} else {
+				reply("250 OK")
+			}
+		case "DATA":
+			reply("354 go ahead")
+			data, err := r.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
+
+func (s *smtpStub) snapshot() (conns int, commands []string, messages [][]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns, append([]string(nil), s.commands...), append([][]byte(nil), s.messages...)
+}
+
+func (s *smtpStub) notifier(tlsMode string) *SMTPNotifier {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return &SMTPNotifier{
+		Email: config.EmailConfig{
+			Host: "127.0.0.1",
+			Port: addr.Port,
+			From: "Bot <bot@example.com>",
+			To:   []string{"ops@example.com"},
+			TLS:  tlsMode,
+		},
+		Config: &config.Config{RetryCount: 3, RetryDelay: 10 * time.Millisecond, Locale: "zh"},
+	}
+}
+
+func testMessage() Message {
+	return Message{
+		Coin:  "BTC",
+		Title: func(f formatter.Formatter, locale string) string { return "BTC 仓位日报" },
+		Body: func(f formatter.Formatter, locale string) string {
+			if f == formatter.HTML {
+				return "<b>BTC</b> 多头占比=75%"
+			}
+			return "BTC 多头占比=75%"
+		},
+	}
+}
+
+func sendTimeout(t *testing.T, n *SMTPNotifier) error {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return n.Send(ctx, testMessage())
+}
+
+func TestSMTPNotifierSendsMultipartAlternative(t *testing.T) {
+	stub := newSMTPStub(t, false, "")
+	if err := sendTimeout(t, stub.notifier(config.EmailTLSNone)); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+
+	_, _, messages := stub.snapshot()
+	if len(messages) != 1 {
+		t.Fatalf("收到 %d 封邮件，期望 1 封", len(messages))
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(messages[0]))
+	if err != nil {
+		t.Fatalf("解析邮件失败: %v", err)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil || subject != "BTC 仓位日报" {
+		t.Errorf("Subject = %q (%v)，期望 %q", subject, err, "BTC 仓位日报")
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q，期望 multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+
+	want := []struct{ contentType, content string }{
+		{"text/plain", "BTC 多头占比=75%"},
+		{"text/html", "<b>BTC</b> 多头占比=75%"},
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for i, w := range want {
+		// NextRawPart 保留 Content-Transfer-Encoding 头，不自动解码
+		part, err := reader.NextRawPart()
+		if err != nil {
+			t.Fatalf("读取第 %d 部分失败: %v", i+1, err)
+		}
+		if ct, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type")); ct != w.contentType {
+			t.Errorf("第 %d 部分 Content-Type = %q，期望 %q", i+1, ct, w.contentType)
+		}
+		if cte := part.Header.Get("Content-Transfer-Encoding"); cte != "quoted-printable" {
+			t.Errorf("第 %d 部分 Content-Transfer-Encoding = %q，期望 quoted-printable", i+1, cte)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatalf("解码第 %d 部分失败: %v", i+1, err)
+		}
+		if !strings.Contains(string(body), w.content) {
+			t.Errorf("第 %d 部分缺少 %q:\n%s", i+1, w.content, body)
+		}
+	}
+	if _, err := reader.NextRawPart(); err != io.EOF {
+		t.Errorf("期望只有两个部分，读取第三部分返回 %v", err)
+	}
+}
+
+func TestSMTPNotifierRequiresStartTLS(t *testing.T) {
+	tests := []struct {
+		name     string
+		startTLS bool // 服务端是否声明 STARTTLS
+	}{
+		{"服务端不支持 STARTTLS", false},
+		{"STARTTLS 握手被拒绝", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newSMTPStub(t, tt.startTLS, "")
+			err := sendTimeout(t, stub.notifier(config.EmailTLSStartTLS))
+			if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+				t.Fatalf("Send() error = %v，期望 STARTTLS 相关错误", err)
+			}
+
+			_, commands, messages := stub.snapshot()
+			for _, cmd := range commands {
+				if cmd == "MAIL" || cmd == "RCPT" || cmd == "DATA" {
+					t.Fatalf("未建立 TLS 就发送了 %s，命令序列: %v", cmd, commands)
+				}
+			}
+			if len(messages) != 0 {
+				t.Fatalf("未建立 TLS 就发送了邮件")
+			}
+		})
+	}
+}
+
+func TestSMTPNotifierRetries(t *testing.T) {
+	tests := []struct {
+		name  string
+		rcpt  string
+		conns int
+	}{
+		{"5xx 不重试", "550 5.1.1 no such user", 1},
+		{"4xx 按次数重试", "451 4.3.0 try again later", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newSMTPStub(t, false, tt.rcpt)
+			err := sendTimeout(t, stub.notifier(config.EmailTLSNone))
+			var protoErr *textproto.Error
+			if !errors.As(err, &protoErr) {
+				t.Fatalf("Send() error = %v，期望包含 SMTP 响应错误", err)
+			}
+
+			conns, _, messages := stub.snapshot()
+			if conns != tt.conns {
+				t.Errorf("建立了 %d 次连接，期望 %d 次", conns, tt.conns)
+			}
+			if len(messages) != 0 {
+				t.Errorf("RCPT 失败后仍发送了邮件")
+			}
+		})
+	}
+}
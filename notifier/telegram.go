@@ -0,0 +1,105 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"hyper-notify-bot/config"
+	"hyper-notify-bot/formatter"
+	"hyper-notify-bot/telegram"
+)
+
+// DashboardStore 保存原地更新模式下每个推送目标、每个币种对应的消息 ID
+type DashboardStore interface {
+	DashboardMessage(ctx context.Context, chatID string, threadID int64, coin string) (int64, error)
+	SaveDashboardMessage(ctx context.Context, chatID string, threadID int64, coin string, messageID int64) error
+	DeleteDashboardMessage(ctx context.Context, chatID string, threadID int64, coin string) error
+}
+
+// TelegramNotifier 推送到 Telegram 群组、频道、论坛话题或私聊
+type TelegramNotifier struct {
+	Bot        *telegram.TelegramBot
+	Dashboards DashboardStore
+	Target     telegram.Target
+	Chat       config.ChatConfig
+	Config     *config.Config
+}
+
+func (n *TelegramNotifier) Name() string {
+	return "Telegram " + n.Target.String()
+}
+
+// Send 按推送目标的消息格式、推送方式和图表设置发送消息
+func (n *TelegramNotifier) Send(ctx context.Context, msg Message) error {
+	f := formatter.ForFormat(n.Chat.Format)
+	text, parseMode := msg.Body(f, n.Chat.Locale), f.ParseMode()
+
+	if n.Chat.Mode == config.MessageModeEdit {
+		return n.updateDashboard(ctx, msg.Coin, text, parseMode)
+	}
+	if n.Chat.Chart == config.ChartOnly && msg.Image != nil {
+		return n.sendImage(ctx, msg.Image)
+	}
+
+	if _, err := n.Bot.SendWithRetry(ctx, n.Target, text, parseMode, n.Config); err != nil {
+		return err
+	}
+	if n.Chat.Chart == config.ChartWith && msg.Image != nil {
+		return n.sendImage(ctx, msg.Image)
+	}
+	return nil
+}
+
+// sendImage 发送图表，图表说明按推送目标的消息格式发送
+func (n *TelegramNotifier) sendImage(ctx context.Context, image *Image) error {
+	f := formatter.ForFormat(n.Chat.Format)
+	_, err := n.Bot.SendPhotoWithRetry(ctx, n.Target, image.Data, image.Name, image.Caption(f, n.Chat.Locale), f.ParseMode(), n.Config)
+	return err
+}
+
+// updateDashboard 原地更新推送目标上币种对应的消息；首次推送或原消息已被删除时发送新消息并置顶
+func (n *TelegramNotifier) updateDashboard(ctx context.Context, coin, text, parseMode string) error {
+	if n.Dashboards == nil {
+		return fmt.Errorf("未配置原地更新消息的存储")
+	}
+	chat, target := n.Chat, n.Target
+
+	messageID, err := n.Dashboards.DashboardMessage(ctx, chat.ID, chat.ThreadID, coin)
+	if err != nil {
+		// 查询失败时不发送新消息，避免重复刷屏
+		return err
+	}
+
+	stale := false
+	if messageID != 0 {
+		err := n.Bot.EditWithRetry(ctx, target, messageID, text, parseMode, nil, n.Config)
+		if err == nil {
+			return nil
+		}
+		if !telegram.IsMessageNotFound(err) {
+			return fmt.Errorf("更新消息 %d 失败: %v", messageID, err)
+		}
+		log.Printf("%s 在 %s 的消息 %d 已不存在，重新发送", coin, target, messageID)
+		stale = true
+	}
+
+	sent, err := n.Bot.SendWithRetry(ctx, target, text, parseMode, n.Config)
+	if err != nil {
+		// 重新发送也失败时删除失效的记录，下次直接发送新消息而不是再次尝试修改已不存在的消息
+		if stale {
+			if delErr := n.Dashboards.DeleteDashboardMessage(ctx, chat.ID, chat.ThreadID, coin); delErr != nil {
+				log.Printf("删除 %s 在 %s 的原地更新消息记录失败: %v", coin, target, delErr)
+			}
+		}
+		return err
+	}
+	if err := n.Bot.PinChatMessage(ctx, target, sent.MessageID, true); err != nil {
+		log.Printf("置顶 %s 在 %s 的消息失败（机器人需要置顶消息权限）: %v", coin, target, err)
+	}
+	if err := n.Dashboards.SaveDashboardMessage(ctx, chat.ID, chat.ThreadID, coin, sent.MessageID); err != nil {
+		return err
+	}
+	log.Printf("已发送 %s 在 %s 的原地更新消息 %d", coin, target, sent.MessageID)
+	return nil
+}
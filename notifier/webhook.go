@@ -0,0 +1,168 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"hyper-notify-bot/config"
+	"hyper-notify-bot/formatter"
+)
+
+// webhook 以 HTTP POST 推送的渠道的公共部分
+type webhook struct {
+	Client *http.Client
+	URL    string
+	Chat   config.ChatConfig
+	Config *config.Config
+}
+
+// name 返回渠道类型和 Webhook 主机名，用于日志
+func (w webhook) name(kind string) string {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return kind
+	}
+	return kind + " " + u.Host
+}
+
+// WebhookError Webhook 返回的非 2xx 响应
+type WebhookError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *WebhookError) Error() string {
+	return fmt.Sprintf("Webhook 返回 %d: %s", e.StatusCode, e.Body)
+}
+
+// temporary 判断是否可以重试：限流和服务端错误
+func (e *WebhookError) temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// postJSON 以 JSON 发送 payload
+func (w webhook) postJSON(ctx context.Context, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %v", err)
+	}
+	return w.post(ctx, "application/json", body)
+}
+
+// post 发送请求，限流和服务端错误按配置的次数重试，限流时按 Retry-After 等待
+func (w webhook) post(ctx context.Context, contentType string, body []byte) error {
+	var lastErr error
+	for attempt := 1; attempt <= w.Config.RetryCount; attempt++ {
+		err := w.postOnce(ctx, contentType, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		log.Printf("Webhook 发送失败 (尝试 %d/%d): %v", attempt, w.Config.RetryCount, err)
+
+		delay := w.Config.RetryDelay
+		if whErr, ok := err.(*WebhookError); ok {
+			if !whErr.temporary() {
+				break
+			}
+			if whErr.RetryAfter > 0 {
+				delay = whErr.RetryAfter
+			}
+		}
+		if attempt == w.Config.RetryCount {
+			break
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("发送被取消: %v", ctx.Err())
+		}
+	}
+	return fmt.Errorf("发送失败，已达最大重试次数: %w", lastErr)
+}
+
+func (w webhook) postOnce(ctx context.Context, contentType string, body []byte) error {
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, "POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	whErr := &WebhookError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	if seconds, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err == nil && seconds > 0 {
+		whErr.RetryAfter = time.Duration(seconds * float64(time.Second))
+	}
+	return whErr
+}
+
+// WebhookNotifier 以 JSON POST 推送到任意 HTTP 地址
+type WebhookNotifier struct {
+	webhook
+}
+
+// webhookPayload 通用 Webhook 的请求体
+type webhookPayload struct {
+	Coin   string        `json:"coin"`
+	Title  string        `json:"title"`
+	Text   string        `json:"text"`
+	HTML   string        `json:"html"`
+	Image  *webhookImage `json:"image,omitempty"`
+	SentAt time.Time     `json:"sent_at"`
+}
+
+// webhookImage 图表，data 为 base64 编码的 PNG
+type webhookImage struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+	Caption     string `json:"caption"`
+}
+
+func (n *WebhookNotifier) Name() string {
+	return n.name("Webhook")
+}
+
+// Send 发送纯文本和 HTML 两种内容，推送目标开启图表时附带 base64 编码的图片
+func (n *WebhookNotifier) Send(ctx context.Context, msg Message) error {
+	locale := n.Chat.Locale
+	payload := webhookPayload{
+		Coin:   msg.Coin,
+		Title:  msg.Title(formatter.Text, locale),
+		Text:   msg.Body(formatter.Text, locale),
+		HTML:   msg.Body(formatter.HTML, locale),
+		SentAt: time.Now().UTC(),
+	}
+	if msg.Image != nil && n.Chat.Chart != config.ChartOff {
+		payload.Image = &webhookImage{
+			Name:        msg.Image.Name,
+			ContentType: "image/png",
+			Data:        msg.Image.Data,
+			Caption:     msg.Image.Caption(formatter.Text, locale),
+		}
+	}
+	return n.postJSON(ctx, payload)
+}
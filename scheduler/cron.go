@@ -5,85 +5,333 @@ import (
 	"fmt"
 	"github.com/robfig/cron/v3"
 	"hyper-notify-bot/config"
+	mongodb "hyper-notify-bot/db"
 	"hyper-notify-bot/formatter"
-	hyperliquid "hyper-notify-bot/hyperLiquid"
+	"hyper-notify-bot/i18n"
+	"hyper-notify-bot/notifier"
 	"hyper-notify-bot/service"
 	"hyper-notify-bot/telegram"
 	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
 )
 
 type CronScheduler struct {
 	Cron        *cron.Cron
-	Bot         *telegram.TelegramBot
+	Notifiers   *notifier.Factory
 	Config      *config.Config
 	DataService *service.DataService
-	WsClient    *hyperliquid.WebSocketClient
+
+	mu      sync.RWMutex
+	entries map[config.Job]cron.EntryID
+	sem     chan struct{} // 限制同时执行的任务数
+	stop    chan struct{} // 关闭时停止价格监控
 }
 
-func NewCronScheduler(bot *telegram.TelegramBot,
+func NewCronScheduler(notifiers *notifier.Factory,
 	cfg *config.Config,
-	dataService *service.DataService,
-	wsClient *hyperliquid.WebSocketClient) *CronScheduler {
+	dataService *service.DataService) *CronScheduler {
 	return &CronScheduler{
-		Cron:        cron.New(cron.WithSeconds()),
-		Bot:         bot,
+		Cron:        cron.New(cron.WithParser(config.ScheduleParser)),
+		Notifiers:   notifiers,
 		Config:      cfg,
 		DataService: dataService,
-		WsClient:    wsClient,
+		sem:         make(chan struct{}, cfg.MaxConcurrentJobs),
+		stop:        make(chan struct{}),
 	}
 }
 
 func (s *CronScheduler) Start() {
 	// 添加定时任务
-	_, err := s.Cron.AddFunc(fmt.Sprintf("0 */%d * * * *", int(s.Config.Interval.Minutes())), s.sendTableJob)
+	entries, err := s.syncJobs(s.Config, nil)
 	if err != nil {
 		log.Fatalf("添加定时任务失败: %v", err)
 	}
+	s.entries = entries
 
 	s.Cron.Start()
+	go s.watchPrices()
 	log.Println("定时任务调度器已启动")
 }
 
+// Reload 使用新配置更新定时任务，只增删推送计划有变化的任务，注册失败时保留原有任务和配置
+func (s *CronScheduler) Reload(cfg *config.Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.syncJobs(cfg, s.entries)
+	if err != nil {
+		return fmt.Errorf("重新注册定时任务失败: %v", err)
+	}
+	s.entries = entries
+	s.setConfigLocked(cfg)
+
+	log.Println("定时任务已重新注册")
+	return nil
+}
+
+// SetConfig 更新配置但不重新注册定时任务，推送目标等在下次执行时生效
+func (s *CronScheduler) SetConfig(cfg *config.Config) {
+	s.mu.Lock()
+	s.setConfigLocked(cfg)
+	s.mu.Unlock()
+}
+
+// setConfigLocked 替换配置，并发上限变化时重建信号量（执行中的任务仍释放到旧信号量）
+func (s *CronScheduler) setConfigLocked(cfg *config.Config) {
+	if cap(s.sem) != cfg.MaxConcurrentJobs {
+		s.sem = make(chan struct{}, cfg.MaxConcurrentJobs)
+	}
+	s.Config = cfg
+}
+
+// syncJobs 按路由表注册定时任务（每个币种、每种推送计划一个任务），上一次未执行完时跳过本次。
+// current 中仍存在的任务原样保留，其 SkipIfStillRunning 状态不受影响；只注册新增的任务并移除不再需要的任务。
+// 任一推送计划解析失败时不做任何修改
+func (s *CronScheduler) syncJobs(cfg *config.Config, current map[config.Job]cron.EntryID) (map[config.Job]cron.EntryID, error) {
+	jobs := cfg.Jobs()
+	schedules := make(map[config.Job]cron.Schedule, len(jobs))
+	for _, job := range jobs {
+		if _, ok := current[job]; ok {
+			continue
+		}
+		schedule, err := config.ScheduleParser.Parse(job.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 推送计划 %q 失败: %v", job.Name(), job.Spec, err)
+		}
+		schedules[job] = schedule
+	}
+
+	logger := cron.VerbosePrintfLogger(log.Default())
+	entries := make(map[config.Job]cron.EntryID, len(jobs))
+	for _, job := range jobs {
+		if id, ok := current[job]; ok {
+			entries[job] = id
+			continue
+		}
+		schedule := schedules[job]
+		wrapped := cron.NewChain(cron.Recover(logger), cron.SkipIfStillRunning(logger)).
+			Then(cron.FuncJob(func() { s.runCoinJob(job) }))
+		entries[job] = s.Cron.Schedule(schedule, wrapped)
+		log.Printf("已注册 %s 定时任务 %s，下次执行时间: %s", job.Name(), job.Spec, nextRuns(schedule, 3))
+	}
+	for job, id := range current {
+		if _, ok := entries[job]; !ok {
+			s.Cron.Remove(id)
+			log.Printf("已移除 %s 定时任务 %s", job.Name(), job.Spec)
+		}
+	}
+	return entries, nil
+}
+
+// nextRuns 返回接下来 n 次执行时间
+func nextRuns(schedule cron.Schedule, n int) string {
+	runs := make([]string, 0, n)
+	next := time.Now()
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next.Format("2006-01-02 15:04:05 MST"))
+	}
+	return strings.Join(runs, ", ")
+}
+
+// CurrentConfig 返回当前生效的配置
+func (s *CronScheduler) CurrentConfig() *config.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Config
+}
+
+// NextRun 返回币种最近一次定时推送的时间，未注册时返回零值
+func (s *CronScheduler) NextRun(coin string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var next time.Time
+	for job, id := range s.entries {
+		if job.Coin != coin {
+			continue
+		}
+		if t := s.Cron.Entry(id).Next; !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return next
+}
+
 func (s *CronScheduler) Stop() {
+	close(s.stop)
 	s.Cron.Stop()
 	s.DataService.Close()
 	log.Println("定时任务调度器已停止")
 }
 
-func (s *CronScheduler) sendTableJob() {
-	s.sendCoinTableJob("HYPE")
-	s.sendCoinTableJob("BTC")
-	s.sendCoinTableJob("ETH")
-	s.sendCoinTableJob("SOL")
+// runCoinJob 在并发上限内执行单个币种的推送任务，并限制执行时间
+func (s *CronScheduler) runCoinJob(job config.Job) {
+	name := job.Name()
+	s.mu.RLock()
+	cfg, sem := s.Config, s.sem
+	s.mu.RUnlock()
+
+	// 排队等待同样以 JobTimeout 为上限，执行超时从取得执行名额后开始计算
+	wait := time.NewTimer(cfg.JobTimeout)
+	select {
+	case sem <- struct{}{}:
+		wait.Stop()
+		defer func() { <-sem }()
+	case <-wait.C:
+		log.Printf("%s 定时任务等待执行超时，跳过本次", name)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.JobTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if job.Digest {
+		s.sendDigestJob(ctx, cfg)
+	} else {
+		// 按默认推送计划执行的任务同时推送给订阅用户
+		var subscribers []mongodb.Subscription
+		if cfg.IsSubscriberJob(job) {
+			var err error
+			if subscribers, err = s.DataService.Subscribers(ctx, job.Coin); err != nil {
+				log.Printf("查询 %s 订阅用户失败: %v", job.Coin, err)
+			}
+		}
+		s.sendCoinTableJob(ctx, cfg, job.Coin, cfg.ChatsForJob(job), subscribers)
+	}
+	if ctx.Err() != nil {
+		log.Printf("%s 定时任务超时 (%v)", name, cfg.JobTimeout)
+	}
+	log.Printf("%s 定时任务完成，耗时 %v", name, time.Since(start).Round(time.Millisecond))
 }
 
-func (s *CronScheduler) sendCoinTableJob(coin string) {
+func (s *CronScheduler) sendCoinTableJob(ctx context.Context, cfg *config.Config, coin string, chats []config.ChatConfig, subscribers []mongodb.Subscription) {
+	if len(chats) == 0 && len(subscribers) == 0 {
+		return
+	}
+
 	log.Println("开始执行定时任务...从MongoDB读取数据")
 
-	// 获取最新的 Oracle 价格
-	var oraclePrice string
-	if price, exists := s.WsClient.GetOraclePrice(coin); exists {
-		oraclePrice = price.OraclePx
-		log.Printf("当前 %s Oracle 价格: %s", coin, oraclePrice)
-	} else {
-		oraclePrice = "N/A"
+	// 获取最新的 Oracle 价格，过期的价格会在报告中标注
+	price := s.DataService.OraclePrice(ctx, coin)
+	switch {
+	case price.Timestamp.IsZero():
 		log.Printf("未找到 %s 的 Oracle 价格", coin)
+	case price.Stale:
+		log.Printf("当前 %s Oracle 价格: %s（已 %v 未更新）", coin, price.OraclePx, price.Age().Round(time.Second))
+	default:
+		log.Printf("当前 %s Oracle 价格: %s（来源 %s）", coin, price.OraclePx, price.Source)
 	}
 
-	// 从服务层获取数据
-	data, longSz, shortSz, err := s.DataService.GetTableData(coin, oraclePrice)
+	// 从服务层获取数据并格式化消息
+	report, err := s.DataService.Report(ctx, coin, price, 0)
 	if err != nil {
 		log.Printf("获取数据失败: %v", err)
 		return
 	}
+	msg := notifier.Message{
+		Coin: coin,
+		Title: func(f formatter.Formatter, locale string) string {
+			return f.Escape(i18n.T(locale, "report.heading", coin))
+		},
+		Body: report.Format,
+	}
 
-	// 格式化消息
-	message := formatter.FormatTableAsHTML(data, coin, oraclePrice, longSz, shortSz)
+	// 有推送目标需要图表时生成一次，所有目标共用
+	for _, chat := range chats {
+		if !notifier.WantsImage(chat) {
+			continue
+		}
+		if chart, err := report.Chart(); err != nil {
+			log.Printf("生成 %s 图表失败，仅发送表格: %v", coin, err)
+		} else {
+			msg.Image = &notifier.Image{Name: strings.ToLower(coin) + ".png", Data: chart, Caption: report.Caption}
+		}
+		break
+	}
 
-	// 发送消息
-	if err := s.Bot.SendWithRetry(context.Background(), message, "HTML", s.Config); err != nil {
-		log.Printf("发送消息失败: %v", err)
-	} else {
-		log.Printf("成功发送 %d 行数据到Telegram", len(data))
+	// 按各目标的渠道和格式发送
+	for _, chat := range chats {
+		n, err := s.Notifiers.ForRoute(cfg, chat)
+		if err != nil {
+			log.Printf("创建推送渠道失败: %v", err)
+			continue
+		}
+		if err := n.Send(ctx, msg); err != nil {
+			log.Printf("发送消息到 %s 失败: %v", n.Name(), err)
+		} else {
+			log.Printf("成功发送 %s 数据到 %s", coin, n.Name())
+		}
 	}
+
+	// 私信订阅用户
+	for _, sub := range subscribers {
+		userID := sub.UserID
+		if err := s.Notifiers.ForUser(cfg, userID, sub.Locale).Send(ctx, msg); err != nil {
+			log.Printf("私信订阅用户 %d 失败: %v", userID, err)
+			// 只有用户屏蔽了机器人或账号已注销时才删除订阅；其他 403（如用户尚未 /start 机器人）只记录日志
+			if apiErr, ok := telegram.AsAPIError(err); ok && apiErr.Code == http.StatusForbidden && subscriberGone(apiErr.Description) {
+				s.DataService.RemoveSubscriber(ctx, userID)
+			}
+		} else {
+			log.Printf("成功私信 %s 数据给订阅用户 %d", coin, userID)
+		}
+	}
+}
+
+// sendDigestJob 汇总所有币种的仓位分布表，通过邮件发送日报
+func (s *CronScheduler) sendDigestJob(ctx context.Context, cfg *config.Config) {
+	log.Println("开始生成邮件日报...")
+
+	var sections []formatter.Content
+	for _, coin := range cfg.Coins {
+		report, err := s.DataService.Report(ctx, coin, s.DataService.OraclePrice(ctx, coin), 0)
+		if err != nil {
+			log.Printf("获取 %s 数据失败: %v", coin, err)
+			sections = append(sections, func(f formatter.Formatter, locale string) string {
+				return f.Bold(coin) + "\n" + f.Escape(i18n.T(locale, "report.failed", err.Error()))
+			})
+			continue
+		}
+		sections = append(sections, report.Format)
+	}
+
+	date := time.Now().Format("2006-01-02")
+	msg := notifier.Message{
+		Title: func(f formatter.Formatter, locale string) string {
+			subject := cfg.Email.Subject
+			if subject == "" {
+				subject = i18n.T(locale, "digest.subject")
+			}
+			return f.Escape(subject + " " + date)
+		},
+		Body: func(f formatter.Formatter, locale string) string {
+			parts := make([]string, len(sections))
+			for i, section := range sections {
+				parts[i] = section(f, locale)
+			}
+			return strings.Join(parts, "\n\n")
+		},
+	}
+
+	n := s.Notifiers.ForEmail(cfg)
+	if err := n.Send(ctx, msg); err != nil {
+		log.Printf("发送邮件日报到 %s 失败: %v", n.Name(), err)
+		return
+	}
+	log.Printf("成功发送邮件日报到 %s", n.Name())
+}
+
+// subscriberGone 根据 403 错误描述判断订阅用户是否已永久无法接收私信
+func subscriberGone(description string) bool {
+	description = strings.ToLower(description)
+	return strings.Contains(description, "bot was blocked by the user") ||
+		strings.Contains(description, "user is deactivated")
 }
@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"hyper-notify-bot/config"
+)
+
+// testConfig 返回两个币种、一个推送目标的配置
+func testConfig() *config.Config {
+	return &config.Config{
+		Coins:             []string{"BTC", "ETH"},
+		Chats:             []config.ChatConfig{{ID: "-100"}},
+		Interval:          time.Hour,
+		MaxConcurrentJobs: 2,
+		JobTimeout:        time.Minute,
+		CoinSettings:      map[string]config.CoinSettings{},
+	}
+}
+
+// newTestScheduler 按 cfg 注册定时任务，不启动调度器，任务不会执行
+func newTestScheduler(t *testing.T, cfg *config.Config) *CronScheduler {
+	t.Helper()
+	s := NewCronScheduler(nil, cfg, nil)
+	entries, err := s.syncJobs(cfg, nil)
+	if err != nil {
+		t.Fatalf("syncJobs() error = %v", err)
+	}
+	s.entries = entries
+	return s
+}
+
+// jobSpecs 返回已注册任务的描述，按任务排序
+func jobSpecs(s *CronScheduler) string {
+	var specs []string
+	for job := range s.entries {
+		specs = append(specs, job.Name()+" "+job.Spec)
+	}
+	sort.Strings(specs)
+	return strings.Join(specs, ", ")
+}
+
+func TestReloadKeepsUnchangedEntries(t *testing.T) {
+	s := newTestScheduler(t, testConfig())
+	before := make(map[config.Job]cron.EntryID, len(s.entries))
+	for job, id := range s.entries {
+		before[job] = id
+	}
+
+	cfg := testConfig()
+	cfg.CoinSettings["ETH"] = config.CoinSettings{Schedule: "30m"}
+	cfg.Coins = append(cfg.Coins, "SOL")
+	if err := s.Reload(cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if got, want := jobSpecs(s), "BTC @every 1h0m0s, ETH @every 30m0s, SOL @every 1h0m0s"; got != want {
+		t.Errorf("已注册任务 %s，期望 %s", got, want)
+	}
+	btc := config.Job{Coin: "BTC", Spec: "@every 1h0m0s"}
+	if s.entries[btc] != before[btc] {
+		t.Errorf("推送计划未变化的 BTC 任务被重新注册: %d -> %d", before[btc], s.entries[btc])
+	}
+	eth := config.Job{Coin: "ETH", Spec: "@every 1h0m0s"}
+	if s.Cron.Entry(before[eth]).ID != 0 {
+		t.Errorf("ETH 原推送计划的任务 %d 未被移除", before[eth])
+	}
+	if n := len(s.Cron.Entries()); n != 3 {
+		t.Errorf("调度器中有 %d 个任务，期望 3 个", n)
+	}
+	if s.CurrentConfig() != cfg {
+		t.Errorf("Reload() 后未使用新配置")
+	}
+}
+
+func TestReloadRollsBackOnInvalidSchedule(t *testing.T) {
+	old := testConfig()
+	s := newTestScheduler(t, old)
+	before := jobSpecs(s)
+
+	// 有效的新增任务和无效的推送计划同时出现时，不应注册任何任务
+	cfg := testConfig()
+	cfg.Coins = append(cfg.Coins, "SOL")
+	cfg.CoinSettings["ETH"] = config.CoinSettings{Schedule: "not a schedule"}
+	if err := s.Reload(cfg); err == nil {
+		t.Fatalf("Reload() 应返回错误")
+	}
+
+	if got := jobSpecs(s); got != before {
+		t.Errorf("注册失败后任务为 %s，期望保留 %s", got, before)
+	}
+	if n := len(s.Cron.Entries()); n != 2 {
+		t.Errorf("调度器中有 %d 个任务，期望保留原有 2 个", n)
+	}
+	if s.CurrentConfig() != old {
+		t.Errorf("注册失败后不应替换配置")
+	}
+}
+
+func TestReloadResizesConcurrencyLimit(t *testing.T) {
+	s := newTestScheduler(t, testConfig())
+
+	cfg := testConfig()
+	cfg.MaxConcurrentJobs = 5
+	if err := s.Reload(cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if cap(s.sem) != 5 {
+		t.Errorf("并发上限为 %d，期望 5", cap(s.sem))
+	}
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"hyper-notify-bot/config"
+	"hyper-notify-bot/formatter"
+	"hyper-notify-bot/i18n"
+	"hyper-notify-bot/notifier"
+)
+
+// priceCheckInterval 检查 Oracle 价格是否过期的间隔
+const priceCheckInterval = 30 * time.Second
+
+// watchPrices 定期检查 WebSocket 推送的 Oracle 价格，价格过期和恢复时各告警一次
+func (s *CronScheduler) watchPrices() {
+	ticker := time.NewTicker(priceCheckInterval)
+	defer ticker.Stop()
+
+	started := time.Now()
+	stale := make(map[string]bool)
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		cfg := s.CurrentConfig()
+		for _, coin := range cfg.Coins {
+			price := s.DataService.FeedPrice(coin)
+			staleAfter := cfg.SettingsFor(coin).StaleAfter
+			// 启动后尚未收到第一条价格时，等待一个过期阈值再告警
+			if price.Timestamp.IsZero() && time.Since(started) < staleAfter {
+				continue
+			}
+			if price.Stale == stale[coin] {
+				continue
+			}
+			stale[coin] = price.Stale
+
+			var key string
+			var args []interface{}
+			switch {
+			case !price.Stale:
+				key, args = "alert.price_recovered", []interface{}{coin, price.OraclePx}
+			case price.Timestamp.IsZero():
+				key, args = "alert.price_missing", []interface{}{coin, staleAfter}
+			default:
+				key, args = "alert.price_stale", []interface{}{coin, price.Age().Round(time.Second), staleAfter}
+			}
+			log.Print(i18n.T(i18n.Default, key, args...))
+			s.sendAlert(cfg, key, args...)
+		}
+	}
+}
+
+// sendAlert 向 AlertChatID 发送运维告警，未配置时跳过
+func (s *CronScheduler) sendAlert(cfg *config.Config, key string, args ...interface{}) {
+	if cfg.AlertChatID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.JobTimeout)
+	defer cancel()
+
+	n := s.Notifiers.ForAlert(cfg)
+	msg := notifier.Message{
+		Body: func(f formatter.Formatter, locale string) string {
+			return f.Escape(i18n.T(locale, key, args...))
+		},
+	}
+	if err := n.Send(ctx, msg); err != nil {
+		log.Printf("发送告警到 %s 失败: %v", n.Name(), err)
+	}
+}
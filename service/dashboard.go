@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"hyper-notify-bot/db"
+)
+
+// DashboardMessage 返回推送目标上币种对应的原地更新消息 ID，不存在时返回 0
+func (ds *DataService) DashboardMessage(ctx context.Context, chatID string, threadID int64, coin string) (int64, error) {
+	dashboard, err := ds.DBClient.GetDashboard(ctx, chatID, threadID, coin)
+	if err != nil || dashboard == nil {
+		return 0, err
+	}
+	return dashboard.MessageID, nil
+}
+
+// SaveDashboardMessage 记录推送目标上币种对应的原地更新消息 ID
+func (ds *DataService) SaveDashboardMessage(ctx context.Context, chatID string, threadID int64, coin string, messageID int64) error {
+	return ds.DBClient.SaveDashboard(ctx, mongodb.Dashboard{
+		ChatID:    chatID,
+		ThreadID:  threadID,
+		Coin:      coin,
+		MessageID: messageID,
+	})
+}
+
+// DeleteDashboardMessage 删除推送目标上币种对应的原地更新消息记录
+func (ds *DataService) DeleteDashboardMessage(ctx context.Context, chatID string, threadID int64, coin string) error {
+	return ds.DBClient.DeleteDashboard(ctx, chatID, threadID, coin)
+}
@@ -5,16 +5,22 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"sync"
 	"time"
 
 	"hyper-notify-bot/config"
 	"hyper-notify-bot/db"
+	"hyper-notify-bot/formatter"
+	hyperliquid "hyper-notify-bot/hyperLiquid"
 )
 
 // DataService 管理数据获取
 type DataService struct {
 	DBClient *mongodb.MongoDBClient
 	Config   *config.Config
+	WsClient *hyperliquid.WebSocketClient // Oracle 价格来源，见 OraclePrice
+	mu       sync.RWMutex
+	snapshot restSnapshot
 }
 
 // NewDataService 创建新的数据服务
@@ -24,6 +30,15 @@ func NewDataService(cfg *config.Config) (*DataService, error) {
 		return nil, err
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := dbClient.EnsureSubscriptionIndexes(ctx); err != nil {
+		log.Printf("警告: %v", err)
+	}
+	if err := dbClient.EnsureDashboardIndexes(ctx); err != nil {
+		log.Printf("警告: %v", err)
+	}
+
 	return &DataService{
 		DBClient: dbClient,
 		Config:   cfg,
@@ -35,40 +50,153 @@ func (ds *DataService) Close() {
 	ds.DBClient.Close()
 }
 
-// GetTableData 获取表格数据（带重试机制）
-func (ds *DataService) GetTableData(coin, oraclePriceStr string) ([]mongodb.PositionResult, float64, float64, error) {
-	var lastErr error
+// SetConfig 热更新配置
+func (ds *DataService) SetConfig(cfg *config.Config) {
+	ds.mu.Lock()
+	ds.Config = cfg
+	ds.mu.Unlock()
+}
 
-	minPrice := float64(47)
-	maxPrice := float64(53)
+// config 返回当前生效的配置
+func (ds *DataService) config() *config.Config {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.Config
+}
 
-	if oraclePriceStr != "N/A" {
-		oraclePrice, _ := strconv.ParseFloat(oraclePriceStr, 64)
-		ratio := ds.Config.PriceRangeRatio
-		minPrice = oraclePrice * (1 - ratio)
-		maxPrice = oraclePrice * (1 + ratio)
-	}
+// CoinSettings 返回结合当前 Oracle 价格推导后的币种参数
+func (ds *DataService) CoinSettings(coin, oraclePriceStr string) config.CoinSettings {
+	oraclePrice, _ := strconv.ParseFloat(oraclePriceStr, 64)
+	return ds.config().SettingsFor(coin).Resolve(oraclePrice)
+}
 
-	for i := 0; i < ds.Config.RetryCount; i++ {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+// ZoomedSettings 返回按缩放级别调整并结合 Oracle 价格推导后的币种参数
+func (ds *DataService) ZoomedSettings(coin, oraclePriceStr string, zoom int) config.CoinSettings {
+	oraclePrice, _ := strconv.ParseFloat(oraclePriceStr, 64)
+	return ds.config().SettingsFor(coin).Zoom(zoom).Resolve(oraclePrice)
+}
 
-		longSz, shortSz, err := ds.DBClient.GetPositionSummary(ctx, coin)
-		if err != nil {
-			return nil, 0, 0, fmt.Errorf("获取数据失败，已达最大重试次数: %v", err)
-		}
+// GetTableData 获取表格数据（带重试机制）
+func (ds *DataService) GetTableData(ctx context.Context, coin, oraclePriceStr string) ([]mongodb.PositionResult, float64, float64, error) {
+	return ds.getTableData(ctx, coin, oraclePriceStr, ds.CoinSettings(coin, oraclePriceStr))
+}
 
-		fmt.Println(longSz, shortSz)
+// getTableData 按指定参数获取表格数据（带重试机制）
+func (ds *DataService) getTableData(ctx context.Context, coin, oraclePriceStr string, settings config.CoinSettings) ([]mongodb.PositionResult, float64, float64, error) {
+	var lastErr error
+
+	cfg := ds.config()
+
+	// 没有 Oracle 价格时无法确定价格窗口，只查询多空汇总，不生成价格分布
+	oraclePrice, err := strconv.ParseFloat(oraclePriceStr, 64)
+	window := err == nil && oraclePrice > 0
+	if !window {
+		log.Printf("%s 没有有效的 Oracle 价格 (%s)，跳过价格分布", coin, oraclePriceStr)
+	}
+	minPrice := oraclePrice * (1 - settings.PriceRangeRatio)
+	maxPrice := oraclePrice * (1 + settings.PriceRangeRatio)
 
-		data, err := ds.DBClient.GetPricePositionSummary(ctx, coin, minPrice, maxPrice)
+	for i := 0; i < cfg.RetryCount; i++ {
+		data, longSz, shortSz, err := ds.queryTableData(ctx, coin, window, minPrice, maxPrice, settings.BinSize)
 		if err == nil {
 			return data, longSz, shortSz, nil
 		}
 
 		lastErr = err
-		log.Printf("获取数据失败 (尝试 %d/%d): %v", i+1, ds.Config.RetryCount, err)
-		time.Sleep(ds.Config.RetryDelay)
+		log.Printf("获取数据失败 (尝试 %d/%d): %v", i+1, cfg.RetryCount, err)
+
+		select {
+		case <-time.After(cfg.RetryDelay):
+		case <-ctx.Done():
+			return nil, 0, 0, fmt.Errorf("获取数据被取消: %v", ctx.Err())
+		}
 	}
 
 	return nil, 0, 0, fmt.Errorf("获取数据失败，已达最大重试次数: %v", lastErr)
 }
+
+// queryTableData 查询一次仓位汇总和分箱数据，window 为 false 时只查询仓位汇总
+func (ds *DataService) queryTableData(ctx context.Context, coin string, window bool, minPrice, maxPrice, binSize float64) ([]mongodb.PositionResult, float64, float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	longSz, shortSz, err := ds.DBClient.GetPositionSummary(ctx, coin)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !window {
+		return nil, longSz, shortSz, nil
+	}
+
+	data, err := ds.DBClient.GetPricePositionSummary(ctx, coin, minPrice, maxPrice, binSize)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return data, longSz, shortSz, nil
+}
+
+// TableReport 生成币种仓位分布的 HTML 报告
+func (ds *DataService) TableReport(ctx context.Context, coin string, price Price, locale string) (string, error) {
+	return ds.ZoomedTableReport(ctx, coin, price, locale, 0)
+}
+
+// ZoomedTableReport 按缩放级别生成币种仓位分布的 HTML 报告，缩放级别见 config.CoinSettings.Zoom
+func (ds *DataService) ZoomedTableReport(ctx context.Context, coin string, price Price, locale string, zoom int) (string, error) {
+	report, err := ds.Report(ctx, coin, price, zoom)
+	if err != nil {
+		return "", err
+	}
+	return report.Format(formatter.HTML, locale), nil
+}
+
+// Report 一次查询得到的仓位分布数据，可分别生成表格和图表
+type Report struct {
+	Coin     string
+	Price    Price
+	Data     []mongodb.PositionResult
+	LongSz   float64
+	ShortSz  float64
+	Settings config.CoinSettings
+}
+
+// Report 按缩放级别查询币种仓位分布
+func (ds *DataService) Report(ctx context.Context, coin string, price Price, zoom int) (*Report, error) {
+	settings := ds.ZoomedSettings(coin, price.OraclePx, zoom)
+	data, longSz, shortSz, err := ds.getTableData(ctx, coin, price.OraclePx, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Report{
+		Coin:     coin,
+		Price:    price,
+		Data:     data,
+		LongSz:   longSz,
+		ShortSz:  shortSz,
+		Settings: settings,
+	}, nil
+}
+
+// view 生成模板数据，价格过期时标注价格的更新时长
+func (r *Report) view() formatter.ReportView {
+	view := formatter.NewReportView(r.Data, r.Coin, r.Price.OraclePx, r.LongSz, r.ShortSz, r.Settings)
+	if r.Price.Stale {
+		view.PriceAge = r.Price.Age()
+	}
+	return view
+}
+
+// Format 按 f 的格式和 locale 语言生成表格
+func (r *Report) Format(f formatter.Formatter, locale string) string {
+	return formatter.FormatReport(f, locale, r.view())
+}
+
+// Chart 生成 PNG 图表
+func (r *Report) Chart() ([]byte, error) {
+	return formatter.RenderPositionChart(r.Data, r.Coin, r.Price.OraclePx, r.LongSz, r.ShortSz, r.Settings)
+}
+
+// Caption 按 f 的格式和 locale 语言生成图表说明
+func (r *Report) Caption(f formatter.Formatter, locale string) string {
+	return formatter.FormatReportCaption(f, locale, r.view())
+}
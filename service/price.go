@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	hyperliquid "hyper-notify-bot/hyperLiquid"
+)
+
+// restSnapshotTTL REST 价格快照的缓存时间，同一轮推送的多个币种共用一次查询
+const restSnapshotTTL = 15 * time.Second
+
+// 价格来源
+const (
+	PriceSourceWebSocket = "websocket"
+	PriceSourceREST      = "rest"
+)
+
+// Price 带时效判断的 Oracle 价格
+type Price struct {
+	Coin      string
+	OraclePx  string    // 未获取到时为 N/A
+	Timestamp time.Time // 价格更新时间，未获取到时为零值
+	Source    string    // 价格来源 websocket 或 rest
+	Stale     bool      // 超过币种的 StaleAfter 未更新，或未获取到价格
+}
+
+// Age 返回价格距上次更新的时长，未获取到价格时返回 0
+func (p Price) Age() time.Duration {
+	if p.Timestamp.IsZero() {
+		return 0
+	}
+	return time.Since(p.Timestamp)
+}
+
+// restSnapshot REST 接口查询的价格快照
+type restSnapshot struct {
+	mu        sync.Mutex
+	prices    map[string]hyperliquid.OraclePrice
+	fetchedAt time.Time
+}
+
+// FeedPrice 返回 WebSocket 推送的 Oracle 价格并判断是否过期，不使用 REST 回退
+func (ds *DataService) FeedPrice(coin string) Price {
+	price := Price{Coin: coin, OraclePx: "N/A", Source: PriceSourceWebSocket, Stale: true}
+	if ds.WsClient == nil {
+		return price
+	}
+	if p, exists := ds.WsClient.GetOraclePrice(coin); exists {
+		price.OraclePx = p.OraclePx
+		price.Timestamp = p.Timestamp
+		price.Stale = price.Age() > ds.config().SettingsFor(coin).StaleAfter
+	}
+	return price
+}
+
+// OraclePrice 返回币种的 Oracle 价格。WebSocket 价格缺失或过期且开启了 PriceRESTFallback 时，
+// 改用 REST 接口的快照；REST 查询失败时仍返回 WebSocket 价格，由调用方按 Stale 标注
+func (ds *DataService) OraclePrice(ctx context.Context, coin string) Price {
+	price := ds.FeedPrice(coin)
+	if !price.Stale || !ds.config().PriceRESTFallback {
+		return price
+	}
+
+	snapshot, err := ds.restPrices(ctx)
+	if err != nil {
+		log.Printf("%s Oracle 价格已过期，REST 回退失败: %v", coin, err)
+		return price
+	}
+	p, exists := snapshot[coin]
+	if !exists {
+		log.Printf("%s Oracle 价格已过期，REST 快照中没有该币种", coin)
+		return price
+	}
+	log.Printf("%s Oracle 价格已过期，使用 REST 快照: %s", coin, p.OraclePx)
+	return Price{Coin: coin, OraclePx: p.OraclePx, Timestamp: p.Timestamp, Source: PriceSourceREST}
+}
+
+// restPrices 返回 REST 价格快照，缓存 restSnapshotTTL
+func (ds *DataService) restPrices(ctx context.Context) (map[string]hyperliquid.OraclePrice, error) {
+	ds.snapshot.mu.Lock()
+	defer ds.snapshot.mu.Unlock()
+
+	if ds.snapshot.prices != nil && time.Since(ds.snapshot.fetchedAt) < restSnapshotTTL {
+		return ds.snapshot.prices, nil
+	}
+	prices, err := hyperliquid.FetchOraclePrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ds.snapshot.prices = prices
+	ds.snapshot.fetchedAt = time.Now()
+	return prices, nil
+}
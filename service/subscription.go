@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"hyper-notify-bot/db"
+)
+
+// Subscribe 为用户订阅币种，返回是否为新订阅；locale 为私信使用的语言
+func (ds *DataService) Subscribe(ctx context.Context, userID int64, username, coin, locale string) (bool, error) {
+	return ds.DBClient.AddSubscription(ctx, mongodb.Subscription{
+		UserID:   userID,
+		Username: username,
+		Coin:     coin,
+		Locale:   locale,
+	})
+}
+
+// Unsubscribe 取消用户对币种的订阅，返回是否存在该订阅
+func (ds *DataService) Unsubscribe(ctx context.Context, userID int64, coin string) (bool, error) {
+	return ds.DBClient.RemoveSubscription(ctx, userID, coin)
+}
+
+// Subscriptions 返回用户订阅的币种
+func (ds *DataService) Subscriptions(ctx context.Context, userID int64) ([]string, error) {
+	subs, err := ds.DBClient.ListSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	coins := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		coins = append(coins, sub.Coin)
+	}
+	return coins, nil
+}
+
+// Subscribers 返回币种的订阅
+func (ds *DataService) Subscribers(ctx context.Context, coin string) ([]mongodb.Subscription, error) {
+	return ds.DBClient.SubscribersFor(ctx, coin)
+}
+
+// RemoveSubscriber 删除用户的所有订阅，用于用户屏蔽机器人或注销账号后停止推送
+func (ds *DataService) RemoveSubscriber(ctx context.Context, userID int64) {
+	removed, err := ds.DBClient.RemoveUserSubscriptions(ctx, userID)
+	if err != nil {
+		log.Printf("删除用户 %d 的订阅失败: %v", userID, err)
+		return
+	}
+	log.Printf("已删除用户 %d 的 %d 个订阅", userID, removed)
+}
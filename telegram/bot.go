@@ -1,24 +1,35 @@
 package telegram
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"golang.org/x/net/proxy"
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"hyper-notify-bot/config"
 )
 
+// DefaultAPIBaseURL 官方 Telegram Bot API 地址
+const DefaultAPIBaseURL = "https://api.telegram.org"
+
 // TelegramBot 结构体封装了Telegram Bot的功能
 type TelegramBot struct {
 	Token  string
 	ChatID string
 	Client *http.Client
+	// APIBaseURL Bot API 地址，可指向自建的 telegram-bot-api 服务或测试用的本地服务，为空时使用官方地址
+	APIBaseURL string
+
+	mu            sync.RWMutex
+	migratedChats map[string]string // 已迁移群组的旧 chat_id -> 新 chat_id
+	username      string            // getMe 返回的机器人用户名，首次获取成功后缓存
 }
 
 // NewTelegramBot 创建一个新的TelegramBot实例
@@ -72,76 +83,115 @@ func NewTelegramBot(token, chatID string, TelegramProxy string) *TelegramBot {
 	}
 }
 
+// apiURL 返回 API 方法的完整地址
+func (b *TelegramBot) apiURL(method string) string {
+	baseURL := b.APIBaseURL
+	if baseURL == "" {
+		baseURL = DefaultAPIBaseURL
+	}
+	return fmt.Sprintf("%s/bot%s/%s", strings.TrimRight(baseURL, "/"), b.Token, method)
+}
+
+// Target 消息发送目标
+type Target struct {
+	ChatID   string // 为空时使用默认 ChatID
+	ThreadID int64  // 论坛话题的 message_thread_id，0 表示不指定
+}
+
+func (t Target) String() string {
+	if t.ThreadID != 0 {
+		return fmt.Sprintf("%s#%d", t.ChatID, t.ThreadID)
+	}
+	return t.ChatID
+}
+
 // sendMessageRequest 定义Telegram发送消息的请求结构
 type sendMessageRequest struct {
-	ChatID    string `json:"chat_id"`
-	Text      string `json:"text"`
-	ParseMode string `json:"parse_mode,omitempty"`
-}
+	ChatID          string `json:"chat_id"`
+	MessageThreadID int64  `json:"message_thread_id,omitempty"`
+	Text            string `json:"text"`
+	ParseMode       string `json:"parse_mode,omitempty"`
 
-// sendMessageResponse 定义Telegram发送消息的响应结构
-type sendMessageResponse struct {
-	OK          bool   `json:"ok"`
-	Description string `json:"description"`
-	ErrorCode   int    `json:"error_code"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
 }
 
-// SendMessage 发送消息到Telegram
-func (b *TelegramBot) SendMessage(ctx context.Context, message string, parseMode string) error {
-	// 创建API URL
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", b.Token)
+// SendMessage 发送消息到Telegram，已迁移的群组自动发送到新 chat_id，返回发送的消息
+func (b *TelegramBot) SendMessage(ctx context.Context, target Target, message string, parseMode string) (*Message, error) {
+	return b.SendMessageWithKeyboard(ctx, target, message, parseMode, nil)
+}
 
+// SendMessageWithKeyboard 发送附带内联键盘的消息，keyboard 为 nil 时不附带键盘
+func (b *TelegramBot) SendMessageWithKeyboard(ctx context.Context, target Target, message string, parseMode string, keyboard *InlineKeyboardMarkup) (*Message, error) {
 	// 构建请求体
 	reqBody := sendMessageRequest{
-		ChatID:    b.ChatID,
-		Text:      message,
-		ParseMode: parseMode,
+		ChatID:          b.resolveChatID(target.ChatID),
+		MessageThreadID: target.ThreadID,
+		Text:            message,
+		ParseMode:       parseMode,
+		ReplyMarkup:     keyboard,
 	}
 
-	// 序列化请求体
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("序列化请求失败: %v", err)
+	var sent Message
+	if err := b.callAPI(ctx, "sendMessage", reqBody, &sent); err != nil {
+		return nil, err
 	}
 
-	// 创建HTTP请求
-	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return fmt.Errorf("创建请求失败: %v", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
+	log.Printf("消息发送成功")
+	return &sent, nil
+}
 
-	// 发送请求
-	resp, err := b.Client.Do(req)
-	if err != nil {
-		return fmt.Errorf("请求失败: %v", err)
+// resolveChatID 返回实际发送的 chat_id：为空时使用默认 ChatID，群组迁移后使用新 chat_id
+func (b *TelegramBot) resolveChatID(chatID string) string {
+	if chatID == "" {
+		chatID = b.ChatID
 	}
-	defer resp.Body.Close()
 
-	// 检查HTTP状态码
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API返回非200状态码: %d", resp.StatusCode)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if migrated, ok := b.migratedChats[chatID]; ok {
+		return migrated
 	}
+	return chatID
+}
 
-	// 解析响应
-	var respBody sendMessageResponse
-	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
-		return fmt.Errorf("解析响应失败: %v", err)
-	}
+// migrateChat 记录群组迁移，之后发往 oldID 的消息都发送到 newID
+func (b *TelegramBot) migrateChat(oldID string, newID int64) string {
+	newChatID := strconv.FormatInt(newID, 10)
 
-	// 检查Telegram API返回的状态
-	if !respBody.OK {
-		return fmt.Errorf("Telegram API错误[%d]: %s", respBody.ErrorCode, respBody.Description)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.migratedChats == nil {
+		b.migratedChats = make(map[string]string)
 	}
+	b.migratedChats[oldID] = newChatID
+	log.Printf("群组 %s 已迁移到 %s，后续消息将发送到新群组，请同步更新配置", oldID, newChatID)
+	return newChatID
+}
 
-	log.Printf("消息发送成功")
-	return nil
+// SendWithRetry 带重试机制的消息发送，返回发送的消息
+func (b *TelegramBot) SendWithRetry(ctx context.Context, target Target, message string, parseMode string, cfg *config.Config) (*Message, error) {
+	return b.SendWithKeyboard(ctx, target, message, parseMode, nil, cfg)
+}
+
+// SendWithKeyboard 带重试机制发送附带内联键盘的消息
+func (b *TelegramBot) SendWithKeyboard(ctx context.Context, target Target, message string, parseMode string, keyboard *InlineKeyboardMarkup, cfg *config.Config) (*Message, error) {
+	var sent *Message
+	err := b.withRetry(ctx, target, cfg, func(ctx context.Context, target Target) error {
+		var err error
+		sent, err = b.SendMessageWithKeyboard(ctx, target, message, parseMode, keyboard)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sent, nil
 }
 
-// SendWithRetry 带重试机制的消息发送
-func (b *TelegramBot) SendWithRetry(ctx context.Context, message string, parseMode string, cfg *config.Config) error {
+// withRetry 按配置的次数重试 API 调用：群组迁移后改用新 chat_id，限流时按 retry_after 等待，永久性错误不重试
+func (b *TelegramBot) withRetry(ctx context.Context, target Target, cfg *config.Config, call func(ctx context.Context, target Target) error) error {
 	var lastErr error
 	attempts := 0
+	migrated := false
 
 	for attempts < cfg.RetryCount {
 		attempts++
@@ -149,10 +199,10 @@ func (b *TelegramBot) SendWithRetry(ctx context.Context, message string, parseMo
 
 		// 创建带超时的上下文
 		reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-		defer cancel()
 
 		// 尝试发送消息
-		err := b.SendMessage(reqCtx, message, parseMode)
+		err := call(reqCtx, target)
+		cancel()
 		if err == nil {
 			return nil // 发送成功
 		}
@@ -160,22 +210,42 @@ func (b *TelegramBot) SendWithRetry(ctx context.Context, message string, parseMo
 		lastErr = err
 		log.Printf("发送失败 (尝试 %d/%d): %v", attempts, cfg.RetryCount, err)
 
+		delay := cfg.RetryDelay
+		if apiErr, ok := AsAPIError(err); ok {
+			// 群组已升级为超级群组，改用新 chat_id 立即重试
+			if newID := apiErr.MigrateToChatID(); newID != 0 && !migrated {
+				target.ChatID = b.migrateChat(b.resolveChatID(target.ChatID), newID)
+				migrated = true
+				attempts-- // 迁移后的重试不计入重试次数
+				continue
+			}
+			// 触发限流，按 Telegram 要求的时间等待
+			if retryAfter := apiErr.RetryAfter(); retryAfter > 0 {
+				log.Printf("触发 Telegram 限流，等待 %v 后重试", retryAfter)
+				delay = retryAfter
+			}
+		}
+
 		// 如果错误是永久性的，不进行重试
 		if isPermanentError(err) {
 			log.Printf("遇到永久性错误，停止重试: %v", err)
 			break
 		}
+		// 最后一次尝试失败后不再等待
+		if attempts == cfg.RetryCount {
+			break
+		}
 
 		// 等待重试延迟
 		select {
-		case <-time.After(cfg.RetryDelay):
+		case <-time.After(delay):
 			// 继续下一次尝试
 		case <-ctx.Done():
 			return fmt.Errorf("发送被取消: %v", ctx.Err())
 		}
 	}
 
-	return fmt.Errorf("发送失败，已达最大重试次数: %v", lastErr)
+	return fmt.Errorf("发送失败，已达最大重试次数: %w", lastErr)
 }
 
 // isPermanentError 判断是否为永久性错误（不需要重试）
@@ -185,7 +255,8 @@ func isPermanentError(err error) bool {
 	}
 
 	// 检查是否是特定类型的错误
-	if apiErr, ok := err.(interface{ ErrorCode() int }); ok {
+	var apiErr interface{ ErrorCode() int }
+	if errors.As(err, &apiErr) {
 		errorCode := apiErr.ErrorCode()
 
 		// Telegram API 永久性错误码
@@ -194,7 +265,6 @@ func isPermanentError(err error) bool {
 			401: true, // Unauthorized
 			403: true, // Forbidden
 			404: true, // Not Found
-			420: true, // Too Many Requests (但有时可重试)
 		}
 
 		if _, exists := permanentErrors[errorCode]; exists {
@@ -212,7 +282,7 @@ func isPermanentError(err error) bool {
 	}
 
 	for _, msg := range permanentErrorMsgs {
-		if contains(errorMsg, msg) {
+		if strings.Contains(errorMsg, msg) {
 			return true
 		}
 	}
@@ -220,52 +290,45 @@ func isPermanentError(err error) bool {
 	return false
 }
 
-// contains 检查字符串是否包含子串
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && s[:len(substr)] == substr
+// GetBotInfo 获取Telegram Bot的基本信息（getMe 返回的 User）
+func (b *TelegramBot) GetBotInfo(ctx context.Context) (*User, error) {
+	var me User
+	if err := b.callAPI(ctx, "getMe", map[string]interface{}{}, &me); err != nil {
+		return nil, err
+	}
+	return &me, nil
 }
 
-// GetBotInfo 获取Telegram Bot的基本信息
-func (b *TelegramBot) GetBotInfo(ctx context.Context) (map[string]interface{}, error) {
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/getMe", b.Token)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %v", err)
+// Username 返回机器人的用户名（不含 @），首次调用时通过 getMe 获取并缓存
+func (b *TelegramBot) Username(ctx context.Context) (string, error) {
+	b.mu.RLock()
+	username := b.username
+	b.mu.RUnlock()
+	if username != "" {
+		return username, nil
 	}
 
-	resp, err := b.Client.Do(req)
+	me, err := b.GetBotInfo(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API返回非200状态码: %d", resp.StatusCode)
+		return "", fmt.Errorf("获取机器人信息失败: %v", err)
 	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
-	}
-
-	if ok, exists := result["ok"]; !exists || !ok.(bool) {
-		return nil, fmt.Errorf("Telegram API错误: %v", result["description"])
-	}
-
-	return result, nil
+	b.mu.Lock()
+	b.username = me.Username
+	b.mu.Unlock()
+	return me.Username, nil
 }
 
 // SendLargeMessage 发送长消息（自动分割）
-func (b *TelegramBot) SendLargeMessage(ctx context.Context, message string, parseMode string) error {
+func (b *TelegramBot) SendLargeMessage(ctx context.Context, target Target, message string, parseMode string) error {
 	// Telegram 消息长度限制为4096个字符
 	const maxLength = 4000 // 留出一些空间
 
 	if len(message) <= maxLength {
-		return b.SendWithRetry(ctx, message, parseMode, &config.Config{
+		_, err := b.SendWithRetry(ctx, target, message, parseMode, &config.Config{
 			RetryCount: 3,
 			RetryDelay: 5 * time.Second,
 		})
+		return err
 	}
 
 	// 分割长消息
@@ -275,7 +338,7 @@ func (b *TelegramBot) SendLargeMessage(ctx context.Context, message string, pars
 		// 添加消息序号
 		fullMsg := fmt.Sprintf("(%d/%d)\n%s", i+1, len(messages), msg)
 
-		if err := b.SendWithRetry(ctx, fullMsg, parseMode, &config.Config{
+		if _, err := b.SendWithRetry(ctx, target, fullMsg, parseMode, &config.Config{
 			RetryCount: 3,
 			RetryDelay: 5 * time.Second,
 		}); err != nil {
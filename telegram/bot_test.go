@@ -0,0 +1,144 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hyper-notify-bot/config"
+)
+
+// apiStub 模拟 Telegram Bot API，按调用顺序返回预设的响应并记录每次 sendMessage 的 chat_id
+type apiStub struct {
+	t         *testing.T
+	responses []string // 依次返回的响应体，用完后重复最后一个
+
+	mu      sync.Mutex
+	chatIDs []string
+	times   []time.Time
+}
+
+func (s *apiStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/botTOKEN/sendMessage") {
+		s.t.Errorf("意外的请求路径 %s", r.URL.Path)
+		http.NotFound(w, r)
+		return
+	}
+	var req struct {
+		ChatID string `json:"chat_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.t.Errorf("解析请求失败: %v", err)
+	}
+
+	s.mu.Lock()
+	n := len(s.chatIDs)
+	s.chatIDs = append(s.chatIDs, req.ChatID)
+	s.times = append(s.times, time.Now())
+	s.mu.Unlock()
+
+	body := s.responses[len(s.responses)-1]
+	if n < len(s.responses) {
+		body = s.responses[n]
+	}
+	var resp apiResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		s.t.Errorf("预设响应无效: %v", err)
+		http.Error(w, "bad stub response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !resp.OK {
+		w.WriteHeader(resp.ErrorCode)
+	}
+	w.Write([]byte(body))
+}
+
+func (s *apiStub) calls() ([]string, []time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.chatIDs...), append([]time.Time(nil), s.times...)
+}
+
+func newTestBot(t *testing.T, responses ...string) (*TelegramBot, *apiStub) {
+	t.Helper()
+	stub := &apiStub{t: t, responses: responses}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	bot := NewTelegramBot("TOKEN", "-100", "")
+	bot.APIBaseURL = srv.URL
+	return bot, stub
+}
+
+const sentOK = `{"ok":true,"result":{"message_id":42,"chat":{"id":-100,"type":"group"},"text":"hi"}}`
+
+func TestSendWithRetryHonorsRetryAfter(t *testing.T) {
+	bot, stub := newTestBot(t,
+		`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`,
+		sentOK,
+	)
+	// RetryDelay 远小于 retry_after，确认等待时间来自 retry_after
+	cfg := &config.Config{RetryCount: 3, RetryDelay: 10 * time.Millisecond}
+
+	sent, err := bot.SendWithRetry(context.Background(), Target{}, "hi", "", cfg)
+	if err != nil {
+		t.Fatalf("SendWithRetry() error = %v", err)
+	}
+	if sent.MessageID != 42 {
+		t.Errorf("MessageID = %d，期望 42", sent.MessageID)
+	}
+
+	chatIDs, times := stub.calls()
+	if len(chatIDs) != 2 {
+		t.Fatalf("调用了 %d 次 sendMessage，期望 2 次", len(chatIDs))
+	}
+	if wait := times[1].Sub(times[0]); wait < time.Second {
+		t.Errorf("限流后 %v 即重试，期望至少等待 retry_after=1s", wait)
+	}
+}
+
+func TestSendWithRetryFollowsChatMigration(t *testing.T) {
+	bot, stub := newTestBot(t,
+		`{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1001234}}`,
+		sentOK,
+	)
+	// 迁移后的重试不计入重试次数，只允许一次尝试时同样应发送到新群组
+	cfg := &config.Config{RetryCount: 1, RetryDelay: 10 * time.Millisecond}
+
+	if _, err := bot.SendWithRetry(context.Background(), Target{}, "hi", "", cfg); err != nil {
+		t.Fatalf("SendWithRetry() error = %v", err)
+	}
+	// 后续发送到旧 chat_id 的消息直接使用新 chat_id
+	if _, err := bot.SendMessage(context.Background(), Target{ChatID: "-100"}, "hi", ""); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	chatIDs, _ := stub.calls()
+	want := []string{"-100", "-1001234", "-1001234"}
+	if strings.Join(chatIDs, ",") != strings.Join(want, ",") {
+		t.Errorf("sendMessage chat_id = %v，期望 %v", chatIDs, want)
+	}
+}
+
+func TestSendWithRetryStopsOnBadRequest(t *testing.T) {
+	bot, stub := newTestBot(t,
+		`{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities"}`,
+	)
+	cfg := &config.Config{RetryCount: 3, RetryDelay: 10 * time.Millisecond}
+
+	_, err := bot.SendWithRetry(context.Background(), Target{}, "<b>hi", "HTML", cfg)
+	apiErr, ok := AsAPIError(err)
+	if !ok || apiErr.Code != http.StatusBadRequest {
+		t.Fatalf("SendWithRetry() error = %v，期望 400 TelegramAPIError", err)
+	}
+
+	if chatIDs, _ := stub.calls(); len(chatIDs) != 1 {
+		t.Errorf("调用了 %d 次 sendMessage，400 错误不应重试", len(chatIDs))
+	}
+}
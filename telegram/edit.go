@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+
+	"hyper-notify-bot/config"
+)
+
+// editMessageTextRequest editMessageText 请求结构
+type editMessageTextRequest struct {
+	ChatID    string `json:"chat_id"`
+	MessageID int64  `json:"message_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode,omitempty"`
+
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// EditMessageText 修改已发送消息的内容和内联键盘，内容未变化时视为成功；keyboard 为 nil 时移除键盘
+func (b *TelegramBot) EditMessageText(ctx context.Context, target Target, messageID int64, message string, parseMode string, keyboard *InlineKeyboardMarkup) error {
+	reqBody := editMessageTextRequest{
+		ChatID:      b.resolveChatID(target.ChatID),
+		MessageID:   messageID,
+		Text:        message,
+		ParseMode:   parseMode,
+		ReplyMarkup: keyboard,
+	}
+
+	if err := b.callAPI(ctx, "editMessageText", reqBody, nil); err != nil {
+		if isDescription(err, "message is not modified") {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// EditWithRetry 带重试机制的消息修改
+func (b *TelegramBot) EditWithRetry(ctx context.Context, target Target, messageID int64, message string, parseMode string, keyboard *InlineKeyboardMarkup, cfg *config.Config) error {
+	return b.withRetry(ctx, target, cfg, func(ctx context.Context, target Target) error {
+		return b.EditMessageText(ctx, target, messageID, message, parseMode, keyboard)
+	})
+}
+
+// PinChatMessage 置顶消息，silent 为 true 时不通知群成员
+func (b *TelegramBot) PinChatMessage(ctx context.Context, target Target, messageID int64, silent bool) error {
+	params := map[string]interface{}{
+		"chat_id":              b.resolveChatID(target.ChatID),
+		"message_id":           messageID,
+		"disable_notification": silent,
+	}
+	return b.callAPI(ctx, "pinChatMessage", params, nil)
+}
+
+// IsMessageNotFound 判断错误是否因为要修改的消息已被删除或不存在
+func IsMessageNotFound(err error) bool {
+	return isDescription(err, "message to edit not found") ||
+		isDescription(err, "message_id_invalid") ||
+		isDescription(err, "message can't be edited")
+}
+
+// isDescription 判断 Telegram API 错误描述是否包含指定内容（不区分大小写）
+func isDescription(err error, text string) bool {
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return false
+	}
+	return strings.Contains(strings.ToLower(apiErr.Description), text)
+}
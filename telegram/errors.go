@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ResponseParameters Telegram 错误响应中附带的参数
+type ResponseParameters struct {
+	MigrateToChatID int64 `json:"migrate_to_chat_id,omitempty"` // 群组升级为超级群组后的新 chat_id
+	RetryAfter      int   `json:"retry_after,omitempty"`        // 触发限流时需要等待的秒数
+}
+
+// TelegramAPIError Telegram API 返回的错误
+type TelegramAPIError struct {
+	Method      string
+	Code        int // error_code，与 HTTP 状态码一致
+	Description string
+	Parameters  *ResponseParameters
+}
+
+func (e *TelegramAPIError) Error() string {
+	msg := fmt.Sprintf("Telegram API错误[%d] %s: %s", e.Code, e.Method, e.Description)
+	if e.Parameters != nil {
+		if e.Parameters.RetryAfter > 0 {
+			msg += fmt.Sprintf(" (retry_after=%ds)", e.Parameters.RetryAfter)
+		}
+		if e.Parameters.MigrateToChatID != 0 {
+			msg += fmt.Sprintf(" (migrate_to_chat_id=%d)", e.Parameters.MigrateToChatID)
+		}
+	}
+	return msg
+}
+
+// ErrorCode 返回 Telegram 错误码
+func (e *TelegramAPIError) ErrorCode() int {
+	return e.Code
+}
+
+// RetryAfter 返回限流时需要等待的时间，未限流时返回 0
+func (e *TelegramAPIError) RetryAfter() time.Duration {
+	if e.Code != http.StatusTooManyRequests || e.Parameters == nil {
+		return 0
+	}
+	return time.Duration(e.Parameters.RetryAfter) * time.Second
+}
+
+// MigrateToChatID 返回群组迁移后的新 chat_id，未迁移时返回 0
+func (e *TelegramAPIError) MigrateToChatID() int64 {
+	if e.Parameters == nil {
+		return 0
+	}
+	return e.Parameters.MigrateToChatID
+}
+
+// AsAPIError 从错误链中取出 TelegramAPIError
+func AsAPIError(err error) (*TelegramAPIError, bool) {
+	var apiErr *TelegramAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
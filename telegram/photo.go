@@ -0,0 +1,79 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"hyper-notify-bot/config"
+)
+
+// maxCaptionLength Telegram 图片说明的长度上限
+const maxCaptionLength = 1024
+
+// SendPhoto 以 multipart/form-data 上传图片，caption 为图片说明，返回发送的消息
+func (b *TelegramBot) SendPhoto(ctx context.Context, target Target, photo []byte, filename, caption, parseMode string) (*Message, error) {
+	if len([]rune(caption)) > maxCaptionLength {
+		return nil, fmt.Errorf("图片说明超过 %d 个字符", maxCaptionLength)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	fields := map[string]string{
+		"chat_id":    b.resolveChatID(target.ChatID),
+		"caption":    caption,
+		"parse_mode": parseMode,
+	}
+	if target.ThreadID != 0 {
+		fields["message_thread_id"] = strconv.FormatInt(target.ThreadID, 10)
+	}
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, fmt.Errorf("构建请求失败: %v", err)
+		}
+	}
+
+	part, err := writer.CreateFormFile("photo", filename)
+	if err != nil {
+		return nil, fmt.Errorf("构建请求失败: %v", err)
+	}
+	if _, err := part.Write(photo); err != nil {
+		return nil, fmt.Errorf("构建请求失败: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("构建请求失败: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", b.apiURL("sendPhoto"), &body)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var sent Message
+	if err := b.doRequest(req, "sendPhoto", &sent); err != nil {
+		return nil, err
+	}
+	return &sent, nil
+}
+
+// SendPhotoWithRetry 带重试机制的图片发送
+func (b *TelegramBot) SendPhotoWithRetry(ctx context.Context, target Target, photo []byte, filename, caption, parseMode string, cfg *config.Config) (*Message, error) {
+	var sent *Message
+	err := b.withRetry(ctx, target, cfg, func(ctx context.Context, target Target) error {
+		var err error
+		sent, err = b.SendPhoto(ctx, target, photo, filename, caption, parseMode)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sent, nil
+}
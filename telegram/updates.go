@@ -0,0 +1,236 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// pollTimeout getUpdates 长轮询的超时时间，需小于 HTTP 客户端超时
+const pollTimeout = 25
+
+// maxConcurrentUpdates 长轮询时同时处理的更新数，达到上限后暂停拉取新的更新
+const maxConcurrentUpdates = 8
+
+// allowedUpdates 机器人接收的更新类型
+var allowedUpdates = []string{"message", "callback_query"}
+
+// Update Telegram 推送的更新
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+// CallbackQuery 用户点击内联键盘按钮产生的回调
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message,omitempty"` // 按钮所在的消息，消息过旧时可能为空
+	Data    string   `json:"data,omitempty"`
+}
+
+// Message Telegram 消息
+type Message struct {
+	MessageID       int64  `json:"message_id"`
+	MessageThreadID int64  `json:"message_thread_id,omitempty"`
+	From            *User  `json:"from,omitempty"`
+	Chat            Chat   `json:"chat"`
+	Date            int64  `json:"date"`
+	Text            string `json:"text,omitempty"`
+}
+
+// User Telegram 用户
+type User struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	FirstName string `json:"first_name"`
+	Username  string `json:"username,omitempty"`
+	// LanguageCode 用户客户端的 IETF 语言标签，如 en、zh-hans
+	LanguageCode string `json:"language_code,omitempty"`
+}
+
+// Chat Telegram 会话
+type Chat struct {
+	ID    int64  `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title,omitempty"`
+}
+
+// InlineKeyboardMarkup 消息下方的内联键盘
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// InlineKeyboardButton 内联键盘按钮，点击后发送 callback_data 回调
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+// BotCommand 命令菜单项
+type BotCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// apiResponse Telegram API 通用响应
+type apiResponse struct {
+	OK          bool                `json:"ok"`
+	Description string              `json:"description"`
+	ErrorCode   int                 `json:"error_code"`
+	Parameters  *ResponseParameters `json:"parameters,omitempty"`
+	Result      json.RawMessage     `json:"result"`
+}
+
+// callAPI 以 JSON 请求调用 Telegram API，并将 result 解析到 result
+func (b *TelegramBot) callAPI(ctx context.Context, method string, params interface{}, result interface{}) error {
+	jsonBody, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", b.apiURL(method), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return b.doRequest(req, method, result)
+}
+
+// doRequest 发送请求并解析 Telegram API 响应，失败时返回 *TelegramAPIError
+func (b *TelegramBot) doRequest(req *http.Request, method string, result interface{}) error {
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 非 200 响应同样包含 error_code、description 和 parameters
+	var respBody apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return &TelegramAPIError{Method: method, Code: resp.StatusCode, Description: http.StatusText(resp.StatusCode)}
+		}
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	if !respBody.OK {
+		code := respBody.ErrorCode
+		if code == 0 {
+			code = resp.StatusCode
+		}
+		return &TelegramAPIError{
+			Method:      method,
+			Code:        code,
+			Description: respBody.Description,
+			Parameters:  respBody.Parameters,
+		}
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(respBody.Result, result); err != nil {
+			return fmt.Errorf("解析返回结果失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetUpdates 长轮询获取 offset 之后的更新
+func (b *TelegramBot) GetUpdates(ctx context.Context, offset int64, timeout int) ([]Update, error) {
+	params := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         timeout,
+		"allowed_updates": allowedUpdates,
+	}
+
+	var updates []Update
+	if err := b.callAPI(ctx, "getUpdates", params, &updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// AnswerCallbackQuery 响应按钮回调，text 非空时向用户显示提示，alert 为 true 时以弹窗显示
+func (b *TelegramBot) AnswerCallbackQuery(ctx context.Context, callbackID, text string, alert bool) error {
+	params := map[string]interface{}{
+		"callback_query_id": callbackID,
+		"text":              text,
+		"show_alert":        alert,
+	}
+	return b.callAPI(ctx, "answerCallbackQuery", params, nil)
+}
+
+// SetMyCommands 设置命令菜单，languageCode 不为空时只对该语言的用户生效
+func (b *TelegramBot) SetMyCommands(ctx context.Context, commands []BotCommand, languageCode string) error {
+	params := map[string]interface{}{"commands": commands}
+	if languageCode != "" {
+		params["language_code"] = languageCode
+	}
+	return b.callAPI(ctx, "setMyCommands", params, nil)
+}
+
+// PollUpdates 持续长轮询更新，每条更新在单独的 goroutine 中交给 handle 处理（最多同时处理
+// maxConcurrentUpdates 条），避免耗时的命令阻塞后续更新，直到 ctx 取消
+func (b *TelegramBot) PollUpdates(ctx context.Context, handle func(Update)) {
+	var offset int64
+	retryDelay := time.Second
+	sem := make(chan struct{}, maxConcurrentUpdates)
+
+	for {
+		updates, err := b.GetUpdates(ctx, offset, pollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("获取Telegram更新失败，%v 后重试: %v", retryDelay, err)
+			select {
+			case <-time.After(retryDelay):
+			case <-ctx.Done():
+				return
+			}
+			if retryDelay < time.Minute {
+				retryDelay *= 2
+			}
+			continue
+		}
+		retryDelay = time.Second
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				defer func() { <-sem }()
+				handle(update)
+			}()
+		}
+	}
+}
+
+// ParseCommand 解析命令消息，返回命令名（不含 / 和 @bot 后缀）及参数。
+// 带 @bot 后缀的命令只有后缀与 botUsername 一致时才返回，群组中发给其他机器人的命令被忽略
+func ParseCommand(text, botUsername string) (string, []string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil, false
+	}
+
+	command := strings.TrimPrefix(fields[0], "/")
+	if i := strings.Index(command, "@"); i >= 0 {
+		// Telegram 用户名不区分大小写
+		if botUsername == "" || !strings.EqualFold(command[i+1:], botUsername) {
+			return "", nil, false
+		}
+		command = command[:i]
+	}
+	return strings.ToLower(command), fields[1:], true
+}
@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"hyper-notify-bot/config"
+)
+
+// secretTokenHeader Telegram 推送 webhook 时携带 secret_token 的请求头
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// SetWebhook 注册 webhook，Telegram 推送更新时会在请求头中携带 secretToken
+func (b *TelegramBot) SetWebhook(ctx context.Context, webhookURL, secretToken string) error {
+	params := map[string]interface{}{
+		"url":             webhookURL,
+		"secret_token":    secretToken,
+		"allowed_updates": allowedUpdates,
+	}
+	return b.callAPI(ctx, "setWebhook", params, nil)
+}
+
+// DeleteWebhook 删除 webhook，使用 getUpdates 长轮询前需要调用
+func (b *TelegramBot) DeleteWebhook(ctx context.Context) error {
+	return b.callAPI(ctx, "deleteWebhook", map[string]interface{}{}, nil)
+}
+
+// WebhookHandler 返回处理 Telegram webhook 推送的 http.Handler，校验 secret token 后异步交给 handle 处理。
+// 与长轮询一样最多同时处理 maxConcurrentUpdates 条更新，达到上限时等待空闲后再响应 Telegram
+func WebhookHandler(secretToken string, handle func(Update)) http.Handler {
+	sem := make(chan struct{}, maxConcurrentUpdates)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secretToken)) != 1 {
+			log.Printf("拒绝 webhook 请求：secret token 不匹配 (%s)", r.RemoteAddr)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		var update Update
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&update); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		// 等待期间请求被取消时不响应 200，Telegram 会稍后重新推送
+		select {
+		case sem <- struct{}{}:
+		case <-r.Context().Done():
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		// 先响应 Telegram，避免处理耗时导致重复推送
+		w.WriteHeader(http.StatusOK)
+		go func() {
+			defer func() { <-sem }()
+			handle(update)
+		}()
+	})
+}
+
+// ServeWebhook 注册 webhook 并启动 HTTP(S) 服务接收更新，直到 ctx 取消
+func (b *TelegramBot) ServeWebhook(ctx context.Context, cfg config.WebhookConfig, handle func(Update)) error {
+	webhookURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return fmt.Errorf("webhook 地址无效: %v", err)
+	}
+	path := webhookURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, WebhookHandler(cfg.Secret, handle))
+	server := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if cfg.CertFile != "" {
+			err = server.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+
+	if err := b.SetWebhook(ctx, cfg.URL, cfg.Secret); err != nil {
+		server.Close()
+		return fmt.Errorf("注册 webhook 失败: %v", err)
+	}
+	log.Printf("webhook 已注册: %s，监听 %s%s", webhookURL.Redacted(), cfg.Listen, path)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("webhook 服务异常退出: %v", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
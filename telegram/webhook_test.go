@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "s3cret_token"
+
+// postUpdate 向 handler 发送一次 webhook 请求并返回响应状态码
+func postUpdate(ctx context.Context, handler http.Handler, method, secret, body string) int {
+	req := httptest.NewRequest(method, "/telegram", strings.NewReader(body)).WithContext(ctx)
+	if secret != "" {
+		req.Header.Set(secretTokenHeader, secret)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestWebhookHandlerRejectsInvalidRequests(t *testing.T) {
+	handler := WebhookHandler(testSecret, func(update Update) {
+		t.Errorf("无效请求不应交给 handle 处理: %+v", update)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		secret string
+		body   string
+		want   int
+	}{
+		{"缺少 secret", http.MethodPost, "", `{"update_id":1}`, http.StatusForbidden},
+		{"secret 不匹配", http.MethodPost, "wrong", `{"update_id":1}`, http.StatusForbidden},
+		{"非 POST 请求", http.MethodGet, testSecret, "", http.StatusMethodNotAllowed},
+		{"JSON 无效", http.MethodPost, testSecret, `{"update_id":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postUpdate(context.Background(), handler, tt.method, tt.secret, tt.body); got != tt.want {
+				t.Errorf("状态码 = %d，期望 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerDeliversUpdate(t *testing.T) {
+	updates := make(chan Update, 1)
+	handler := WebhookHandler(testSecret, func(update Update) { updates <- update })
+
+	body := `{"update_id":42,"message":{"message_id":7,"chat":{"id":-100,"type":"group"},"text":"/price BTC"}}`
+	if got := postUpdate(context.Background(), handler, http.MethodPost, testSecret, body); got != http.StatusOK {
+		t.Fatalf("状态码 = %d，期望 200", got)
+	}
+
+	select {
+	case update := <-updates:
+		if update.UpdateID != 42 || update.Message == nil || update.Message.Text != "/price BTC" {
+			t.Errorf("收到的更新 = %+v", update)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("更新没有交给 handle 处理")
+	}
+}
+
+func TestWebhookHandlerLimitsConcurrency(t *testing.T) {
+	started := make(chan struct{}, maxConcurrentUpdates+1)
+	release := make(chan struct{})
+	handler := WebhookHandler(testSecret, func(update Update) {
+		started <- struct{}{}
+		<-release
+	})
+
+	// 占满处理名额
+	for i := 0; i < maxConcurrentUpdates; i++ {
+		if got := postUpdate(context.Background(), handler, http.MethodPost, testSecret, `{"update_id":1}`); got != http.StatusOK {
+			t.Fatalf("第 %d 个请求状态码 = %d，期望 200", i+1, got)
+		}
+	}
+	for i := 0; i < maxConcurrentUpdates; i++ {
+		<-started
+	}
+
+	// 达到上限后新的更新等待空闲，请求取消时不返回 200，由 Telegram 重新推送
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if got := postUpdate(ctx, handler, http.MethodPost, testSecret, `{"update_id":2}`); got == http.StatusOK {
+		t.Fatalf("超过并发上限的请求不应立即返回 200")
+	}
+	select {
+	case <-started:
+		t.Fatalf("超过并发上限时不应开始处理新的更新")
+	default:
+	}
+
+	// 释放名额后可以继续处理
+	close(release)
+	if got := postUpdate(context.Background(), handler, http.MethodPost, testSecret, `{"update_id":3}`); got != http.StatusOK {
+		t.Fatalf("释放名额后状态码 = %d，期望 200", got)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("释放名额后更新没有交给 handle 处理")
+	}
+}